@@ -130,7 +130,7 @@ func (br *bridge) serve() error {
 			}
 
 			if ind, ok := msg.(*cemi.LDataInd); ok {
-				util.Log(br, "%+v", ind)
+				util.Log(br, "%s", cemi.FormatMessage(ind, nil))
 				if err := br.other.relay(ind.LData); err != nil {
 					return err
 				}
@@ -143,7 +143,7 @@ func (br *bridge) serve() error {
 			}
 
 			if ind, ok := msg.(*cemi.LDataInd); ok {
-				util.Log(br, "%+v", ind)
+				util.Log(br, "%s", cemi.FormatMessage(ind, nil))
 				if err := br.tunnel.Send(&cemi.LDataReq{LData: ind.LData}); err != nil {
 					return err
 				}
@@ -0,0 +1,208 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+var (
+	errNoDeviceInProgMode        = errors.New("no device is in programming mode")
+	errMultipleDevicesInProgMode = errors.New("more than one device is in programming mode")
+)
+
+// ReadIndividualAddresses returns the individual addresses of all devices that are in programming
+// mode.
+func (mgmt *Management) ReadIndividualAddresses() ([]cemi.IndividualAddr, error) {
+	responses, err := mgmt.collectBroadcast(
+		false,
+		&cemi.AppData{Command: cemi.IndividualAddrRequest},
+		func(event BroadcastEvent) bool {
+			return event.App.Command == cemi.IndividualAddrResponse
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]cemi.IndividualAddr, 0, len(responses))
+	seen := map[cemi.IndividualAddr]bool{}
+
+	for _, res := range responses {
+		if !seen[res.Source] {
+			seen[res.Source] = true
+			addrs = append(addrs, res.Source)
+		}
+	}
+
+	return addrs, nil
+}
+
+// WriteIndividualAddress assigns the address to all devices that are in programming mode.
+func (mgmt *Management) WriteIndividualAddress(addr cemi.IndividualAddr) error {
+	return mgmt.sendBroadcast(false, &cemi.AppData{
+		Command: cemi.IndividualAddrWrite,
+		Data:    []byte{0, byte(addr >> 8), byte(addr)},
+	})
+}
+
+// ReadIndividualAddressBySerial returns the individual address of the device with the given serial
+// number.
+func (mgmt *Management) ReadIndividualAddressBySerial(serial cemi.SerialNumber) (cemi.IndividualAddr, error) {
+	responses, err := mgmt.collectBroadcast(
+		false,
+		cemi.NewExtendedAppData(cemi.IndividualAddrSerialNumberRead, serial[:]),
+		func(event BroadcastEvent) bool {
+			res := event.App
+			if res.ExtendedCommand() != cemi.IndividualAddrSerialNumberResponse || len(res.Data) < 7 {
+				return false
+			}
+
+			var other cemi.SerialNumber
+			copy(other[:], res.Data[1:7])
+
+			return other == serial
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(responses) == 0 {
+		return 0, fmt.Errorf("no device with serial number %v responded", serial)
+	}
+
+	return responses[0].Source, nil
+}
+
+// WriteIndividualAddressBySerial assigns the address to the device with the given serial number.
+// The device does not need to be in programming mode.
+func (mgmt *Management) WriteIndividualAddressBySerial(serial cemi.SerialNumber, addr cemi.IndividualAddr) error {
+	data := make([]byte, 12)
+	copy(data, serial[:])
+	data[6] = byte(addr >> 8)
+	data[7] = byte(addr)
+
+	return mgmt.sendBroadcast(false, cemi.NewExtendedAppData(cemi.IndividualAddrSerialNumberWrite, data))
+}
+
+// IndividualAddressInUse determines whether a device with the given address exists. It tries to
+// connect to the address and read the device descriptor.
+func (mgmt *Management) IndividualAddressInUse(addr cemi.IndividualAddr) (bool, error) {
+	conn, err := mgmt.Connect(addr)
+	if err != nil {
+		return false, err
+	}
+
+	defer conn.Close()
+
+	_, err = conn.DeviceDescriptor(0)
+
+	switch err {
+	case errAckTimeout:
+		return false, nil
+
+	case nil, errResponseTimeout, errDeviceDisconnected:
+		// The device has at least acknowledged our request or closed the connection, e.g. because
+		// it is busy or connected to someone else.
+		return true, nil
+
+	default:
+		return false, err
+	}
+}
+
+// ProgramIndividualAddress assigns the address to the single device that is in programming mode.
+// It makes sure that no other device uses the address already, verifies the assignment and
+// restarts the device afterwards, so that it leaves programming mode.
+func (mgmt *Management) ProgramIndividualAddress(addr cemi.IndividualAddr) error {
+	addrs, err := mgmt.ReadIndividualAddresses()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(addrs) == 0:
+		return errNoDeviceInProgMode
+
+	case len(addrs) > 1:
+		return errMultipleDevicesInProgMode
+	}
+
+	if addrs[0] != addr {
+		inUse, err := mgmt.IndividualAddressInUse(addr)
+		if err != nil {
+			return err
+		}
+
+		if inUse {
+			return fmt.Errorf("individual address %v is already in use", addr)
+		}
+
+		if err := mgmt.WriteIndividualAddress(addr); err != nil {
+			return err
+		}
+
+		addrs, err = mgmt.ReadIndividualAddresses()
+		if err != nil {
+			return err
+		}
+
+		if len(addrs) != 1 || addrs[0] != addr {
+			return fmt.Errorf("device did not accept individual address %v", addr)
+		}
+	}
+
+	return mgmt.restart(addr)
+}
+
+// ProgramIndividualAddressBySerial assigns the address to the device with the given serial number.
+// It makes sure that no other device uses the address already and verifies the assignment.
+func (mgmt *Management) ProgramIndividualAddressBySerial(serial cemi.SerialNumber, addr cemi.IndividualAddr) error {
+	current, err := mgmt.ReadIndividualAddressBySerial(serial)
+	if err != nil {
+		return err
+	}
+
+	if current == addr {
+		return nil
+	}
+
+	inUse, err := mgmt.IndividualAddressInUse(addr)
+	if err != nil {
+		return err
+	}
+
+	if inUse {
+		return fmt.Errorf("individual address %v is already in use", addr)
+	}
+
+	if err := mgmt.WriteIndividualAddressBySerial(serial, addr); err != nil {
+		return err
+	}
+
+	current, err = mgmt.ReadIndividualAddressBySerial(serial)
+	if err != nil {
+		return err
+	}
+
+	if current != addr {
+		return fmt.Errorf("device %v did not accept individual address %v", serial, addr)
+	}
+
+	return nil
+}
+
+// restart connects to the device and restarts it.
+func (mgmt *Management) restart(addr cemi.IndividualAddr) error {
+	conn, err := mgmt.Connect(addr)
+	if err != nil {
+		return err
+	}
+
+	return conn.Restart()
+}
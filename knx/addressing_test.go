@@ -0,0 +1,142 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+// newSimulatedSetup connects the device to a Management which uses short timeouts.
+func newSimulatedSetup(dev *dummyDevice) (*Management, func()) {
+	client := newDummyClient()
+	dev.client = client
+
+	go dev.simulate()
+
+	mgmt := newManagement(client, ManagementConfig{
+		AckTimeout:      10 * time.Millisecond,
+		ResponseTimeout: 50 * time.Millisecond,
+	})
+
+	return mgmt, func() {
+		close(client.outbound)
+		close(client.inbound)
+	}
+}
+
+func TestManagement_ProgramIndividualAddress(t *testing.T) {
+	t.Run("Ok", func(t *testing.T) {
+		dev := &dummyDevice{addr: cemi.NewIndividualAddr3(15, 15, 255), progMode: true}
+		mgmt, cleanup := newSimulatedSetup(dev)
+		defer cleanup()
+
+		addr := cemi.NewIndividualAddr3(1, 1, 7)
+
+		if err := mgmt.ProgramIndividualAddress(addr); err != nil {
+			t.Fatal(err)
+		}
+
+		addrs, err := mgmt.ReadIndividualAddresses()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(addrs) != 0 {
+			t.Errorf("Device should have left programming mode: %v", addrs)
+		}
+	})
+
+	t.Run("NoDevice", func(t *testing.T) {
+		dev := &dummyDevice{addr: cemi.NewIndividualAddr3(15, 15, 255)}
+		mgmt, cleanup := newSimulatedSetup(dev)
+		defer cleanup()
+
+		if err := mgmt.ProgramIndividualAddress(cemi.NewIndividualAddr3(1, 1, 7)); err != errNoDeviceInProgMode {
+			t.Fatalf("Expected error %v, got %v", errNoDeviceInProgMode, err)
+		}
+	})
+
+	t.Run("InUse", func(t *testing.T) {
+		dev := &dummyDevice{addr: cemi.NewIndividualAddr3(1, 1, 7), progMode: true}
+		mgmt, cleanup := newSimulatedSetup(dev)
+		defer cleanup()
+
+		inUse, err := mgmt.IndividualAddressInUse(cemi.NewIndividualAddr3(1, 1, 7))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !inUse {
+			t.Error("Address should be in use")
+		}
+
+		inUse, err = mgmt.IndividualAddressInUse(cemi.NewIndividualAddr3(1, 1, 8))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if inUse {
+			t.Error("Address should not be in use")
+		}
+	})
+
+	t.Run("Disconnect", func(t *testing.T) {
+		client := newDummyClient()
+		defer close(client.inbound)
+
+		addr := cemi.NewIndividualAddr3(1, 1, 7)
+		dev := &dummyDevice{client: client, addr: addr}
+
+		mgmt := newManagement(client, ManagementConfig{
+			AckTimeout:      time.Second,
+			ResponseTimeout: time.Second,
+		})
+
+		// The device rejects the request, like a device which is connected to someone else.
+		go func() {
+			for i := 0; i < 2; i++ {
+				if _, err := dev.next(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+
+			dev.send(&cemi.ControlData{Command: transportDisconnect})
+		}()
+
+		inUse, err := mgmt.IndividualAddressInUse(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !inUse {
+			t.Error("Address should be in use")
+		}
+	})
+}
+
+func TestManagement_ProgramIndividualAddressBySerial(t *testing.T) {
+	serial := cemi.SerialNumber{0x00, 0x83, 0x12, 0x34, 0x56, 0x78}
+	dev := &dummyDevice{addr: cemi.NewIndividualAddr3(15, 15, 255), serial: serial}
+	mgmt, cleanup := newSimulatedSetup(dev)
+	defer cleanup()
+
+	addr := cemi.NewIndividualAddr3(1, 1, 9)
+
+	if err := mgmt.ProgramIndividualAddressBySerial(serial, addr); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := mgmt.ReadIndividualAddressBySerial(serial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current != addr {
+		t.Errorf("Unexpected address: %v", current)
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+var errManagementClosed = errors.New("management client has terminated")
+
+// A BroadcastEvent is a frame in broadcast or system broadcast communication mode, i.e. a frame
+// to the group address 0.
+type BroadcastEvent struct {
+	// System determines whether the frame is a system broadcast. System broadcasts reach devices
+	// in all domains on open media.
+	System bool
+
+	Source cemi.IndividualAddr
+	App    *cemi.AppData
+}
+
+// An IndividualAddrEvent is an individual address service in broadcast mode.
+type IndividualAddrEvent struct {
+	// One of cemi.IndividualAddrWrite, cemi.IndividualAddrRequest or cemi.IndividualAddrResponse
+	Command cemi.APCI
+	Source  cemi.IndividualAddr
+
+	// New address; only set for cemi.IndividualAddrWrite
+	Address cemi.IndividualAddr
+}
+
+// A DomainAddressEvent is a domain address service in system broadcast mode.
+type DomainAddressEvent struct {
+	// One of cemi.DomainAddressWrite, cemi.DomainAddressRead or cemi.DomainAddressResponse
+	Command cemi.ExtendedAPCI
+	Source  cemi.IndividualAddr
+
+	// Domain address; 2 bytes on PL110, 6 bytes on RF. Empty for cemi.DomainAddressRead.
+	Domain []byte
+}
+
+// A NetworkParameterEvent is a network parameter service.
+type NetworkParameterEvent struct {
+	// One of cemi.NetworkParameterRead, cemi.NetworkParameterResponse or
+	// cemi.NetworkParameterWrite
+	Command    cemi.ExtendedAPCI
+	Source     cemi.IndividualAddr
+	ObjectType cemi.InterfaceObjectType
+	PropertyID uint8
+
+	// Test info for reads, test info followed by the test result for responses, value for writes
+	Data []byte
+}
+
+// Decode converts the event into one of the typed events *IndividualAddrEvent,
+// *DomainAddressEvent or *NetworkParameterEvent.
+func (event BroadcastEvent) Decode() (interface{}, error) {
+	app := event.App
+	if app == nil {
+		return nil, errors.New("broadcast event does not contain application data")
+	}
+
+	switch app.Command {
+	case cemi.IndividualAddrRequest, cemi.IndividualAddrResponse:
+		return &IndividualAddrEvent{Command: app.Command, Source: event.Source}, nil
+
+	case cemi.IndividualAddrWrite:
+		if len(app.Data) < 3 {
+			return nil, errors.New("individual address write is too short")
+		}
+
+		return &IndividualAddrEvent{
+			Command: app.Command,
+			Source:  event.Source,
+			Address: cemi.IndividualAddr(app.Data[1])<<8 | cemi.IndividualAddr(app.Data[2]),
+		}, nil
+	}
+
+	switch command := app.ExtendedCommand(); command {
+	case cemi.DomainAddressWrite, cemi.DomainAddressRead, cemi.DomainAddressResponse:
+		return &DomainAddressEvent{
+			Command: command,
+			Source:  event.Source,
+			Domain:  app.Data[1:],
+		}, nil
+
+	case cemi.NetworkParameterRead, cemi.NetworkParameterResponse, cemi.NetworkParameterWrite:
+		if len(app.Data) < 4 {
+			return nil, errors.New("network parameter service is too short")
+		}
+
+		return &NetworkParameterEvent{
+			Command:    command,
+			Source:     event.Source,
+			ObjectType: cemi.InterfaceObjectType(app.Data[1])<<8 | cemi.InterfaceObjectType(app.Data[2]),
+			PropertyID: app.Data[3],
+			Data:       app.Data[4:],
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported broadcast service %v", command)
+	}
+}
+
+// sendBroadcast transmits the application data in broadcast or system broadcast communication
+// mode.
+func (mgmt *Management) sendBroadcast(system bool, app *cemi.AppData) error {
+	ldata := cemi.LData{
+		Control1: cemi.Control1NoRepeat | cemi.Control1NoSysBroadcast | cemi.Control1WantAck |
+			cemi.Control1Prio(cemi.PrioSystem),
+		Control2:    cemi.Control2GroupAddr | cemi.Control2Hops(6),
+		Destination: 0,
+		Data:        app,
+	}
+
+	ldata.Control1.SetSysBroadcast(system)
+	ldata.SetFrameType()
+
+	return mgmt.client.Send(&cemi.LDataReq{LData: ldata})
+}
+
+// SendBroadcast transmits the application data of the event in broadcast or system broadcast
+// communication mode. The source is filled in by the gateway.
+func (mgmt *Management) SendBroadcast(event BroadcastEvent) error {
+	if event.App == nil {
+		return errors.New("broadcast event does not contain application data")
+	}
+
+	return mgmt.sendBroadcast(event.System, event.App)
+}
+
+// listen registers a listener for broadcast frames. The returned function unregisters it.
+func (mgmt *Management) listen() (<-chan BroadcastEvent, func()) {
+	listener := make(chan BroadcastEvent, 32)
+
+	mgmt.mu.Lock()
+	mgmt.listeners[listener] = struct{}{}
+	mgmt.mu.Unlock()
+
+	return listener, func() {
+		mgmt.mu.Lock()
+		delete(mgmt.listeners, listener)
+		mgmt.mu.Unlock()
+	}
+}
+
+// Broadcasts subscribes to incoming broadcast and system broadcast frames. Call the returned
+// function to unsubscribe. The channel is closed when the management client terminates. Frames are
+// dropped if the channel is not drained in time.
+func (mgmt *Management) Broadcasts() (<-chan BroadcastEvent, func()) {
+	return mgmt.listen()
+}
+
+// collectBroadcast sends the application data as broadcast and collects the responses for which
+// match returns true until the response timeout has been reached.
+func (mgmt *Management) collectBroadcast(
+	system bool,
+	app *cemi.AppData,
+	match func(BroadcastEvent) bool,
+) ([]BroadcastEvent, error) {
+	listener, unlisten := mgmt.listen()
+	defer unlisten()
+
+	if err := mgmt.sendBroadcast(system, app); err != nil {
+		return nil, err
+	}
+
+	timeout := time.NewTimer(mgmt.config.ResponseTimeout)
+	defer timeout.Stop()
+
+	var responses []BroadcastEvent
+
+	for {
+		select {
+		case <-timeout.C:
+			return responses, nil
+
+		case event, open := <-listener:
+			if !open {
+				return responses, errManagementClosed
+			}
+
+			if match(event) {
+				responses = append(responses, event)
+			}
+		}
+	}
+}
+
+// ReadDomainAddresses asks all devices in programming mode for their domain address.
+func (mgmt *Management) ReadDomainAddresses() ([]*DomainAddressEvent, error) {
+	responses, err := mgmt.collectBroadcast(
+		true,
+		cemi.NewExtendedAppData(cemi.DomainAddressRead, nil),
+		func(event BroadcastEvent) bool {
+			return event.App.ExtendedCommand() == cemi.DomainAddressResponse
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*DomainAddressEvent, 0, len(responses))
+
+	for _, res := range responses {
+		if event, err := res.Decode(); err == nil {
+			events = append(events, event.(*DomainAddressEvent))
+		}
+	}
+
+	return events, nil
+}
+
+// WriteDomainAddress assigns the domain address to all devices in programming mode.
+func (mgmt *Management) WriteDomainAddress(domain []byte) error {
+	if len(domain) != 2 && len(domain) != 6 {
+		return fmt.Errorf("invalid domain address length %d", len(domain))
+	}
+
+	return mgmt.sendBroadcast(true, cemi.NewExtendedAppData(cemi.DomainAddressWrite, domain))
+}
+
+// networkParameterData generates the payload of a network parameter service.
+func networkParameterData(objectType cemi.InterfaceObjectType, propertyID uint8, data []byte) []byte {
+	return append([]byte{byte(objectType >> 8), byte(objectType), propertyID}, data...)
+}
+
+// ReadNetworkParameter reads the property from all devices which satisfy the test info. The
+// request is sent in broadcast mode. The responses contain the test info followed by the test
+// result.
+func (mgmt *Management) ReadNetworkParameter(
+	objectType cemi.InterfaceObjectType,
+	propertyID uint8,
+	testInfo []byte,
+) ([]*NetworkParameterEvent, error) {
+	responses, err := mgmt.collectBroadcast(
+		false,
+		cemi.NewExtendedAppData(cemi.NetworkParameterRead, networkParameterData(objectType, propertyID, testInfo)),
+		func(event BroadcastEvent) bool {
+			app := event.App
+			return app.ExtendedCommand() == cemi.NetworkParameterResponse && len(app.Data) >= 4 &&
+				cemi.InterfaceObjectType(app.Data[1])<<8|cemi.InterfaceObjectType(app.Data[2]) == objectType &&
+				app.Data[3] == propertyID
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*NetworkParameterEvent, 0, len(responses))
+
+	for _, res := range responses {
+		if event, err := res.Decode(); err == nil {
+			events = append(events, event.(*NetworkParameterEvent))
+		}
+	}
+
+	return events, nil
+}
+
+// WriteNetworkParameter writes the property value to all devices in broadcast mode. Devices do
+// not respond.
+func (mgmt *Management) WriteNetworkParameter(objectType cemi.InterfaceObjectType, propertyID uint8, value []byte) error {
+	return mgmt.sendBroadcast(
+		false,
+		cemi.NewExtendedAppData(cemi.NetworkParameterWrite, networkParameterData(objectType, propertyID, value)),
+	)
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+// injectBroadcast delivers a broadcast frame from the given source to the management client.
+func injectBroadcast(client *dummyClient, source cemi.IndividualAddr, system bool, app *cemi.AppData) {
+	ldata := cemi.LData{
+		Control1: cemi.Control1StdFrame | cemi.Control1NoSysBroadcast,
+		Control2: cemi.Control2GroupAddr | cemi.Control2Hops(6),
+		Source:   source,
+		Data:     app,
+	}
+
+	ldata.Control1.SetSysBroadcast(system)
+	client.inbound <- &cemi.LDataInd{LData: ldata}
+}
+
+func TestManagement_NetworkParameter(t *testing.T) {
+	client := newDummyClient()
+	defer close(client.inbound)
+
+	mgmt := newManagement(client, ManagementConfig{ResponseTimeout: 50 * time.Millisecond})
+	source := cemi.NewIndividualAddr3(1, 1, 7)
+
+	go func() {
+		msg := <-client.outbound
+		req := msg.(*cemi.LDataReq)
+
+		if req.Control1.SysBroadcast() || !req.Control2.IsGroupAddr() || req.Destination != 0 {
+			t.Errorf("Unexpected request frame: %v", req)
+		}
+
+		// Response for a different property and the expected response
+		injectBroadcast(client, source, false, cemi.NewExtendedAppData(
+			cemi.NetworkParameterResponse, []byte{0, 0, 12, 1, 0x42},
+		))
+		injectBroadcast(client, source, false, cemi.NewExtendedAppData(
+			cemi.NetworkParameterResponse, []byte{0, 0, 11, 1, 0x42},
+		))
+	}()
+
+	events, err := mgmt.ReadNetworkParameter(cemi.ObjectDevice, 11, []byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*NetworkParameterEvent{{
+		Command:    cemi.NetworkParameterResponse,
+		Source:     source,
+		ObjectType: cemi.ObjectDevice,
+		PropertyID: 11,
+		Data:       []byte{1, 0x42},
+	}}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+
+	if err := mgmt.WriteNetworkParameter(cemi.ObjectDevice, 11, []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if req := (<-client.outbound).(*cemi.LDataReq); req.Control1.SysBroadcast() {
+		t.Errorf("Write should not be a system broadcast: %v", req)
+	}
+}
+
+func TestManagement_Broadcasts(t *testing.T) {
+	client := newDummyClient()
+	defer close(client.inbound)
+
+	mgmt := newManagement(client, ManagementConfig{})
+
+	broadcasts, unsubscribe := mgmt.Broadcasts()
+	defer unsubscribe()
+
+	source := cemi.NewIndividualAddr3(1, 1, 7)
+	injectBroadcast(client, source, true, cemi.NewExtendedAppData(cemi.DomainAddressWrite, []byte{0x12, 0x34}))
+
+	// Frames to other group addresses are not broadcasts.
+	client.inbound <- &cemi.LDataInd{LData: cemi.LData{
+		Control2:    cemi.Control2GroupAddr,
+		Destination: 1,
+		Data:        &cemi.AppData{Command: cemi.GroupValueWrite, Data: []byte{1}},
+	}}
+
+	injectBroadcast(client, source, false, &cemi.AppData{Command: cemi.IndividualAddrResponse})
+
+	var events []interface{}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-broadcasts:
+			decoded, err := event.Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			events = append(events, decoded)
+
+		case <-time.After(time.Second):
+			t.Fatal("Timeout while waiting for broadcast")
+		}
+	}
+
+	expected := []interface{}{
+		&DomainAddressEvent{Command: cemi.DomainAddressWrite, Source: source, Domain: []byte{0x12, 0x34}},
+		&IndividualAddrEvent{Command: cemi.IndividualAddrResponse, Source: source},
+	}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+
+	if err := mgmt.WriteDomainAddress([]byte{0xAB, 0xCD}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := (<-client.outbound).(*cemi.LDataReq)
+	app := req.Data.(*cemi.AppData)
+
+	if !req.Control1.SysBroadcast() || app.ExtendedCommand() != cemi.DomainAddressWrite ||
+		!bytes.Equal(app.Data[1:], []byte{0xAB, 0xCD}) {
+		t.Errorf("Unexpected request: %v", req)
+	}
+
+	if err := mgmt.WriteDomainAddress([]byte{1}); err == nil {
+		t.Error("Invalid domain address should be rejected")
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
+)
+
+// GroupState is the latest value of a group address.
+type GroupState struct {
+	Address cemi.GroupAddr
+	Source  cemi.IndividualAddr
+	Data    []byte
+	Time    time.Time
+}
+
+// GroupCacheConfig allows you to configure the group state cache.
+type GroupCacheConfig struct {
+	// ReadInterval is the pause between two GroupValueRead requests during initialisation.
+	ReadInterval time.Duration
+
+	// SubscriptionBuffer is the number of changes that a subscription can hold before further
+	// changes are dropped.
+	SubscriptionBuffer int
+
+	// Types assigns datapoint types (e.g. "9.001") to group addresses. It is needed for hysteresis
+	// filtering.
+	Types map[cemi.GroupAddr]string
+}
+
+// DefaultGroupCacheConfig is a good default configuration for a group state cache.
+var DefaultGroupCacheConfig = GroupCacheConfig{
+	ReadInterval:       50 * time.Millisecond,
+	SubscriptionBuffer: 16,
+}
+
+// checkGroupCacheConfig validates the given GroupCacheConfig.
+func checkGroupCacheConfig(config GroupCacheConfig) GroupCacheConfig {
+	if config.ReadInterval <= 0 {
+		config.ReadInterval = DefaultGroupCacheConfig.ReadInterval
+	}
+
+	if config.SubscriptionBuffer <= 0 {
+		config.SubscriptionBuffer = DefaultGroupCacheConfig.SubscriptionBuffer
+	}
+
+	return config
+}
+
+// SubscribeOptions control which changes are delivered to a subscription.
+type SubscribeOptions struct {
+	// Addresses limits the subscription to the given group addresses. All addresses are included
+	// if it is empty.
+	Addresses []cemi.GroupAddr
+
+	// ChangeOnly suppresses values that are identical to the previously delivered value.
+	ChangeOnly bool
+
+	// Hysteresis suppresses values of numeric datapoints which differ less than this amount from
+	// the previously delivered value. The datapoint type must be known to the cache.
+	Hysteresis float64
+}
+
+// A GroupSubscription receives changes of the group state cache.
+type GroupSubscription struct {
+	cache     *GroupCache
+	options   SubscribeOptions
+	addrs     map[cemi.GroupAddr]struct{}
+	delivered map[cemi.GroupAddr]GroupState
+	changes   chan GroupState
+}
+
+// Changes returns the channel on which changes are delivered. It is closed when the subscription
+// or the cache is closed.
+func (sub *GroupSubscription) Changes() <-chan GroupState {
+	return sub.changes
+}
+
+// Close ends the subscription.
+func (sub *GroupSubscription) Close() {
+	sub.cache.mu.Lock()
+	defer sub.cache.mu.Unlock()
+
+	if _, ok := sub.cache.subs[sub]; ok {
+		delete(sub.cache.subs, sub)
+		close(sub.changes)
+	}
+}
+
+// accepts determines whether the state shall be delivered.
+func (sub *GroupSubscription) accepts(state GroupState, types map[cemi.GroupAddr]string) bool {
+	if len(sub.addrs) > 0 {
+		if _, ok := sub.addrs[state.Address]; !ok {
+			return false
+		}
+	}
+
+	previous, ok := sub.delivered[state.Address]
+	if !ok {
+		return true
+	}
+
+	if sub.options.ChangeOnly && bytes.Equal(previous.Data, state.Data) {
+		return false
+	}
+
+	if sub.options.Hysteresis > 0 {
+		name, ok := types[state.Address]
+		if !ok {
+			return true
+		}
+
+		prevValue, ok1 := numericValue(name, previous.Data)
+		value, ok2 := numericValue(name, state.Data)
+
+		if ok1 && ok2 && math.Abs(value-prevValue) < sub.options.Hysteresis {
+			return false
+		}
+	}
+
+	return true
+}
+
+// numericValue decodes the data as the given datapoint type, if the type is numeric.
+func numericValue(name string, data []byte) (float64, bool) {
+	value, ok := dpt.Produce(name)
+	if !ok || value.Unpack(data) != nil {
+		return 0, false
+	}
+
+	elem := reflect.ValueOf(value).Elem()
+
+	switch elem.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return elem.Float(), true
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		return float64(elem.Int()), true
+
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		return float64(elem.Uint()), true
+	}
+
+	return 0, false
+}
+
+// A GroupCache records the latest value of each group address that is seen by a group client.
+type GroupCache struct {
+	client GroupClient
+	config GroupCacheConfig
+
+	mu      sync.Mutex
+	states  map[cemi.GroupAddr]GroupState
+	subs    map[*GroupSubscription]struct{}
+	updated chan struct{}
+	closed  bool
+}
+
+// NewGroupCache creates a cache which consumes the inbound events of the given client.
+func NewGroupCache(client GroupClient, config GroupCacheConfig) *GroupCache {
+	cache := &GroupCache{
+		client:  client,
+		config:  checkGroupCacheConfig(config),
+		states:  make(map[cemi.GroupAddr]GroupState),
+		subs:    make(map[*GroupSubscription]struct{}),
+		updated: make(chan struct{}),
+	}
+
+	go cache.serve()
+
+	return cache
+}
+
+// serve records the inbound events of the client.
+func (cache *GroupCache) serve() {
+	for event := range cache.client.Inbound() {
+		if event.Command == GroupRead {
+			continue
+		}
+
+		cache.update(GroupState{
+			Address: event.Destination,
+			Source:  event.Source,
+			Data:    event.Data,
+			Time:    time.Now(),
+		})
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.closed = true
+
+	for sub := range cache.subs {
+		delete(cache.subs, sub)
+		close(sub.changes)
+	}
+}
+
+// update stores the state and notifies the subscriptions.
+func (cache *GroupCache) update(state GroupState) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.states[state.Address] = state
+
+	for sub := range cache.subs {
+		if !sub.accepts(state, cache.config.Types) {
+			continue
+		}
+
+		select {
+		case sub.changes <- state:
+			sub.delivered[state.Address] = state
+
+		default:
+			// The subscriber is too slow.
+		}
+	}
+
+	// Wake up everyone waiting for an update.
+	close(cache.updated)
+	cache.updated = make(chan struct{})
+}
+
+// Get retrieves the latest value of a group address.
+func (cache *GroupCache) Get(addr cemi.GroupAddr) (GroupState, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	state, ok := cache.states[addr]
+	return state, ok
+}
+
+// States returns a snapshot of all known values.
+func (cache *GroupCache) States() map[cemi.GroupAddr]GroupState {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	states := make(map[cemi.GroupAddr]GroupState, len(cache.states))
+	for addr, state := range cache.states {
+		states[addr] = state
+	}
+
+	return states
+}
+
+// Subscribe to changes of the cache.
+func (cache *GroupCache) Subscribe(options SubscribeOptions) *GroupSubscription {
+	sub := &GroupSubscription{
+		cache:     cache,
+		options:   options,
+		addrs:     make(map[cemi.GroupAddr]struct{}, len(options.Addresses)),
+		delivered: make(map[cemi.GroupAddr]GroupState),
+		changes:   make(chan GroupState, cache.config.SubscriptionBuffer),
+	}
+
+	for _, addr := range options.Addresses {
+		sub.addrs[addr] = struct{}{}
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.closed {
+		close(sub.changes)
+	} else {
+		cache.subs[sub] = struct{}{}
+	}
+
+	return sub
+}
+
+// Send a group event using the underlying client.
+func (cache *GroupCache) Send(event GroupEvent) error {
+	return cache.client.Send(event)
+}
+
+// Init issues GroupValueRead requests for the given addresses, pausing ReadInterval between them.
+// It waits until all addresses have a value or the context is done, and returns the addresses
+// that did not respond. An error is returned if not all requests could be sent.
+func (cache *GroupCache) Init(ctx context.Context, addrs []cemi.GroupAddr) ([]cemi.GroupAddr, error) {
+	ticker := time.NewTicker(cache.config.ReadInterval)
+	defer ticker.Stop()
+
+	for i, addr := range addrs {
+		if i > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return cache.missing(addrs), ctx.Err()
+			}
+		}
+
+		if err := cache.client.Send(GroupEvent{Command: GroupRead, Destination: addr}); err != nil {
+			return cache.missing(addrs), err
+		}
+	}
+
+	for {
+		cache.mu.Lock()
+		updated := cache.updated
+		cache.mu.Unlock()
+
+		missing := cache.missing(addrs)
+		if len(missing) == 0 {
+			return nil, nil
+		}
+
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return missing, nil
+		}
+	}
+}
+
+// missing returns the addresses which do not have a value.
+func (cache *GroupCache) missing(addrs []cemi.GroupAddr) []cemi.GroupAddr {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	var missing []cemi.GroupAddr
+
+	for _, addr := range addrs {
+		if _, ok := cache.states[addr]; !ok {
+			missing = append(missing, addr)
+		}
+	}
+
+	return missing
+}
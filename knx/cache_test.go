@@ -0,0 +1,125 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
+)
+
+func TestGroupCache_Init(t *testing.T) {
+	client := newDummyGroupClient()
+	defer close(client.inbound)
+
+	cache := NewGroupCache(client, GroupCacheConfig{ReadInterval: time.Millisecond})
+
+	answered := cemi.NewGroupAddr3(1, 0, 1)
+	silent := cemi.NewGroupAddr3(1, 0, 2)
+	source := cemi.NewIndividualAddr3(1, 1, 10)
+
+	go func() {
+		for i := 0; i < 2; i++ {
+			event := <-client.outbound
+			if event.Command != GroupRead {
+				t.Errorf("Unexpected command: %v", event.Command)
+			}
+
+			if event.Destination == answered {
+				client.inbound <- GroupEvent{
+					Command:     GroupResponse,
+					Source:      source,
+					Destination: answered,
+					Data:        []byte{1},
+				}
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	missing, err := cache.Init(ctx, []cemi.GroupAddr{answered, silent})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(missing) != 1 || missing[0] != silent {
+		t.Errorf("Unexpected missing addresses: %v", missing)
+	}
+
+	state, ok := cache.Get(answered)
+	if !ok || state.Source != source || !bytes.Equal(state.Data, []byte{1}) || state.Time.IsZero() {
+		t.Errorf("Unexpected state: %+v", state)
+	}
+
+	if _, ok := cache.Get(silent); ok {
+		t.Error("Silent address should not have a state")
+	}
+
+	if states := cache.States(); len(states) != 1 {
+		t.Errorf("Unexpected states: %v", states)
+	}
+}
+
+func TestGroupCache_Subscribe(t *testing.T) {
+	client := newDummyGroupClient()
+
+	tempAddr := cemi.NewGroupAddr3(2, 0, 1)
+	otherAddr := cemi.NewGroupAddr3(2, 0, 2)
+
+	cache := NewGroupCache(client, GroupCacheConfig{
+		Types: map[cemi.GroupAddr]string{tempAddr: "9.001"},
+	})
+
+	all := cache.Subscribe(SubscribeOptions{})
+	filtered := cache.Subscribe(SubscribeOptions{
+		Addresses:  []cemi.GroupAddr{tempAddr},
+		ChangeOnly: true,
+		Hysteresis: 0.5,
+	})
+
+	temperatures := []float32{20, 20, 20.2, 20.6, 21}
+	for _, temp := range temperatures {
+		value := dpt.DPT_9001(temp)
+		client.inbound <- GroupEvent{Command: GroupWrite, Destination: tempAddr, Data: value.Pack()}
+	}
+
+	client.inbound <- GroupEvent{Command: GroupWrite, Destination: otherAddr, Data: []byte{1}}
+	close(client.inbound)
+
+	count := 0
+	for range all.Changes() {
+		count++
+	}
+
+	if count != len(temperatures)+1 {
+		t.Errorf("Unfiltered subscription received %d changes", count)
+	}
+
+	var received []float32
+	for state := range filtered.Changes() {
+		var value dpt.DPT_9001
+		if err := value.Unpack(state.Data); err != nil {
+			t.Fatal(err)
+		}
+
+		received = append(received, float32(value))
+	}
+
+	if len(received) != 2 || received[0] != 20 || received[1] < 20.5 || received[1] > 20.7 {
+		t.Errorf("Unexpected filtered changes: %v", received)
+	}
+
+	// Closing twice must be harmless.
+	filtered.Close()
+
+	if _, ok := <-cache.Subscribe(SubscribeOptions{}).Changes(); ok {
+		t.Error("Subscription of a closed cache should be closed")
+	}
+}
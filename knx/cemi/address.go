@@ -156,3 +156,44 @@ func NewGroupAddrString(addr string) (GroupAddr, error) {
 func (addr GroupAddr) String() string {
 	return fmt.Sprintf("%d/%d/%d", uint8(addr>>11)&0x1F, uint8(addr>>8)&0x7, uint8(addr))
 }
+
+// SerialNumber is the unique KNX serial number of a device. The first two bytes identify the
+// manufacturer.
+type SerialNumber [6]byte
+
+// NewSerialNumberString parses the given string to a serial number. Supported formats are
+// "mmmm:nnnnnnnn" and "mmmmnnnnnnnn" using hexadecimal digits.
+func NewSerialNumberString(serial string) (SerialNumber, error) {
+	var sn SerialNumber
+
+	// The colon may only separate the manufacturer code from the rest.
+	digits := serial
+	if len(serial) == 13 && serial[4] == ':' {
+		digits = serial[:4] + serial[5:]
+	}
+
+	if len(digits) != 12 {
+		return sn, fmt.Errorf("invalid serial number %s", serial)
+	}
+
+	for i := range sn {
+		b, err := strconv.ParseUint(digits[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return sn, fmt.Errorf("invalid serial number %s", serial)
+		}
+
+		sn[i] = byte(b)
+	}
+
+	return sn, nil
+}
+
+// Manufacturer returns the manufacturer code.
+func (sn SerialNumber) Manufacturer() uint16 {
+	return uint16(sn[0])<<8 | uint16(sn[1])
+}
+
+// String generates a string representation "mmmm:nnnnnnnn".
+func (sn SerialNumber) String() string {
+	return fmt.Sprintf("%02X%02X:%02X%02X%02X%02X", sn[0], sn[1], sn[2], sn[3], sn[4], sn[5])
+}
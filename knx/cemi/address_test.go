@@ -90,3 +90,144 @@ func Test_GroupAddresses(t *testing.T) {
 		}
 	}
 }
+
+// Test Serial Numbers
+func Test_SerialNumbers(t *testing.T) {
+	type Serial struct {
+		Src     string
+		Valid   bool
+		Printed string
+	}
+
+	var serials = []Serial{
+		{"0083:12345678", true, "0083:12345678"},
+		{"00fa0102abcd", true, "00FA:0102ABCD"},
+		{"0083:1234567", false, ""},
+		{"0083::2345678", false, ""},
+		{"0083:1234567G", false, ""},
+		{"00:8312345678", false, ""},
+		{"008312345678:", false, ""},
+		{"0083123:45678", false, ""},
+		{"", false, ""},
+	}
+
+	for _, s := range serials {
+		sn, err := NewSerialNumberString(s.Src)
+		if s.Valid {
+			if err != nil {
+				t.Errorf("%#v has error %s.", s.Src, err)
+			} else if sn.String() != s.Printed {
+				t.Errorf("%#v wrongly parsed.", s.Src)
+			}
+		} else if err == nil {
+			t.Errorf("%#v invalid parsed.", s.Src)
+		}
+	}
+}
+
+func TestGroupAddrRange(t *testing.T) {
+	cases := []struct {
+		src         string
+		first, last GroupAddr
+	}{
+		{"*", 0, 0xFFFF},
+		{"1/*", NewGroupAddr3(1, 0, 0), NewGroupAddr3(1, 7, 255)},
+		{"1/*/*", NewGroupAddr3(1, 0, 0), NewGroupAddr3(1, 7, 255)},
+		{"1/2/*", NewGroupAddr3(1, 2, 0), NewGroupAddr3(1, 2, 255)},
+		{"1/2/10-20", NewGroupAddr3(1, 2, 10), NewGroupAddr3(1, 2, 20)},
+		{"1/2/3", NewGroupAddr3(1, 2, 3), NewGroupAddr3(1, 2, 3)},
+		{"1/100-2000", NewGroupAddr2(1, 100), NewGroupAddr2(1, 2000)},
+		{"100-200", 100, 200},
+		{"1/2/10-1/3/20", NewGroupAddr3(1, 2, 10), NewGroupAddr3(1, 3, 20)},
+	}
+
+	for _, c := range cases {
+		r, err := NewGroupAddrRangeString(c.src)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", c.src, err)
+			continue
+		}
+
+		if r.First != c.first || r.Last != c.last {
+			t.Errorf("Unexpected range for %q: %v", c.src, r)
+		}
+
+		if !r.Contains(c.first) || !r.Contains(c.last) || (c.first > 0 && r.Contains(c.first-1)) {
+			t.Errorf("Unexpected membership for %q", c.src)
+		}
+	}
+
+	for _, invalid := range []string{"", "32/*", "1/8/*", "1/2/20-10", "1/2/3/*", "1/*/3", "1/2/3-4-5", "1/2/256"} {
+		if _, err := NewGroupAddrRangeString(invalid); err == nil {
+			t.Errorf("Should not succeed: %q", invalid)
+		}
+	}
+
+	if str := (GroupAddrRange{NewGroupAddr3(1, 2, 0), NewGroupAddr3(1, 2, 255)}).String(); str != "1/2/0-1/2/255" {
+		t.Errorf("Unexpected string representation: %s", str)
+	}
+}
+
+func TestIndividualAddrRange(t *testing.T) {
+	r, err := NewIndividualAddrRangeString("1.1.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.First != NewIndividualAddr3(1, 1, 0) || r.Last != NewIndividualAddr3(1, 1, 255) {
+		t.Errorf("Unexpected range: %v", r)
+	}
+
+	r, err = NewIndividualAddrRangeString("1.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Contains(NewIndividualAddr3(1, 15, 255)) || r.Contains(NewIndividualAddr3(2, 0, 0)) {
+		t.Errorf("Unexpected range: %v", r)
+	}
+
+	if _, err := NewIndividualAddrRangeString("16.*"); err == nil {
+		t.Error("Should not succeed with invalid area")
+	}
+}
+
+func TestAddrSets(t *testing.T) {
+	var groups GroupAddrSet
+
+	groups.Add(NewGroupAddr3(1, 2, 3))
+	groups.AddRange(GroupAddrRange{NewGroupAddr3(2, 0, 0), NewGroupAddr3(2, 0, 9)})
+	groups.Add(0xFFFF)
+
+	if groups.Len() != 12 || !groups.Contains(NewGroupAddr3(2, 0, 5)) || groups.Contains(NewGroupAddr3(2, 0, 10)) {
+		t.Errorf("Unexpected group set with %d members", groups.Len())
+	}
+
+	groups.Remove(NewGroupAddr3(1, 2, 3))
+	if groups.Contains(NewGroupAddr3(1, 2, 3)) {
+		t.Error("Address should have been removed")
+	}
+
+	var individuals IndividualAddrSet
+
+	individuals.AddRange(IndividualAddrRange{0, 0xFFFF})
+	if individuals.Len() != 65536 {
+		t.Errorf("Unexpected individual set with %d members", individuals.Len())
+	}
+}
+
+func TestGroupAddr_Format(t *testing.T) {
+	addr := NewGroupAddr3(1, 2, 3)
+
+	cases := map[GroupAddrStyle]string{
+		GroupAddrStyle3Level: "1/2/3",
+		GroupAddrStyle2Level: "1/515",
+		GroupAddrStyleFree:   "2563",
+	}
+
+	for style, expected := range cases {
+		if str := addr.Format(style); str != expected {
+			t.Errorf("Unexpected format: %s, expected %s", str, expected)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package cemi
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// These are the bit widths of the levels of the address notations, indexed by the number of
+// levels.
+var (
+	groupAddrLevels      = [][]uint{nil, {16}, {5, 11}, {5, 3, 8}}
+	individualAddrLevels = [][]uint{nil, {16}, {8, 8}, {4, 4, 8}}
+)
+
+// parseAddrNum parses a single level of an address.
+func parseAddrNum(s string, width uint) (uint16, error) {
+	num, err := strconv.ParseUint(s, 10, 16)
+	if err != nil || num >= 1<<width {
+		return 0, fmt.Errorf("invalid address level %q", s)
+	}
+
+	return uint16(num), nil
+}
+
+// composeAddr assembles an address from its levels. Missing levels are filled with fill.
+func composeAddr(parts []string, widths []uint, fill bool) (uint16, error) {
+	var addr uint16
+
+	for i, width := range widths {
+		var num uint16
+
+		if i < len(parts) {
+			var err error
+			if num, err = parseAddrNum(parts[i], width); err != nil {
+				return 0, err
+			}
+		} else if fill {
+			num = 1<<width - 1
+		}
+
+		addr = addr<<width | num
+	}
+
+	return addr, nil
+}
+
+// parseAddrRange parses a range in the notation with the given separator. Supported are
+// wildcards at the end ("1/*", "1/2/*"), ranges in the last level ("1/2/10-20"), full ranges
+// ("1/2/10-1/3/20") and single addresses.
+func parseAddrRange(s string, sep string, levels [][]uint) (uint16, uint16, error) {
+	if s == "*" {
+		return 0, 0xFFFF, nil
+	}
+
+	// Full range with two complete addresses
+	if bounds := strings.Split(s, "-"); len(bounds) == 2 && strings.Contains(bounds[1], sep) {
+		first, _, err := parseAddrRange(bounds[0], sep, levels)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		last, _, err := parseAddrRange(bounds[1], sep, levels)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if first > last {
+			return 0, 0, fmt.Errorf("invalid address range %q", s)
+		}
+
+		return first, last, nil
+	}
+
+	parts := strings.Split(s, sep)
+
+	if parts[len(parts)-1] == "*" {
+		// Wildcards refer to the 3-level notation. Trailing wildcards can be repeated.
+		for len(parts) > 0 && parts[len(parts)-1] == "*" {
+			parts = parts[:len(parts)-1]
+		}
+
+		if len(parts) == 0 || len(parts) >= 3 {
+			return 0, 0, fmt.Errorf("invalid address range %q", s)
+		}
+
+		first, err := composeAddr(parts, levels[3], false)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		last, _ := composeAddr(parts, levels[3], true)
+
+		return first, last, nil
+	}
+
+	if len(parts) > 3 {
+		return 0, 0, fmt.Errorf("invalid address range %q", s)
+	}
+
+	widths := levels[len(parts)]
+	prefix := parts[:len(parts)-1]
+
+	var firstPart, lastPart string
+	if bounds := strings.Split(parts[len(parts)-1], "-"); len(bounds) == 2 {
+		firstPart, lastPart = bounds[0], bounds[1]
+	} else if len(bounds) == 1 {
+		firstPart, lastPart = bounds[0], bounds[0]
+	} else {
+		return 0, 0, fmt.Errorf("invalid address range %q", s)
+	}
+
+	first, err := composeAddr(append(prefix[:len(prefix):len(prefix)], firstPart), widths, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	last, err := composeAddr(append(prefix[:len(prefix):len(prefix)], lastPart), widths, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if first > last {
+		return 0, 0, fmt.Errorf("invalid address range %q", s)
+	}
+
+	return first, last, nil
+}
+
+// GroupAddrRange is a contiguous range of group addresses.
+type GroupAddrRange struct {
+	First GroupAddr
+	Last  GroupAddr
+}
+
+// NewGroupAddrRangeString parses a range of group addresses. Supported formats are "*", wildcards
+// in 3-level notation ("1/*", "1/2/*"), ranges in the last level ("1/2/10-20", "1/100-200",
+// "100-200"), full ranges ("1/2/10-1/3/20") and single addresses.
+func NewGroupAddrRangeString(s string) (GroupAddrRange, error) {
+	first, last, err := parseAddrRange(s, "/", groupAddrLevels)
+	return GroupAddrRange{GroupAddr(first), GroupAddr(last)}, err
+}
+
+// Contains determines whether the address is part of the range.
+func (r GroupAddrRange) Contains(addr GroupAddr) bool {
+	return r.First <= addr && addr <= r.Last
+}
+
+// String generates a string representation.
+func (r GroupAddrRange) String() string {
+	if r.First == r.Last {
+		return r.First.String()
+	}
+
+	return r.First.String() + "-" + r.Last.String()
+}
+
+// IndividualAddrRange is a contiguous range of individual addresses.
+type IndividualAddrRange struct {
+	First IndividualAddr
+	Last  IndividualAddr
+}
+
+// NewIndividualAddrRangeString parses a range of individual addresses. Supported formats are "*",
+// wildcards in 3-level notation ("1.*", "1.1.*"), ranges in the last level ("1.1.10-20") full
+// ranges ("1.1.10-1.2.20") and single addresses.
+func NewIndividualAddrRangeString(s string) (IndividualAddrRange, error) {
+	first, last, err := parseAddrRange(s, ".", individualAddrLevels)
+	return IndividualAddrRange{IndividualAddr(first), IndividualAddr(last)}, err
+}
+
+// Contains determines whether the address is part of the range.
+func (r IndividualAddrRange) Contains(addr IndividualAddr) bool {
+	return r.First <= addr && addr <= r.Last
+}
+
+// String generates a string representation.
+func (r IndividualAddrRange) String() string {
+	if r.First == r.Last {
+		return r.First.String()
+	}
+
+	return r.First.String() + "-" + r.Last.String()
+}
+
+// addrBitmap is a set of 16-bit addresses.
+type addrBitmap [1024]uint64
+
+func (set *addrBitmap) add(addr uint16) {
+	set[addr>>6] |= 1 << (addr & 63)
+}
+
+func (set *addrBitmap) remove(addr uint16) {
+	set[addr>>6] &^= 1 << (addr & 63)
+}
+
+func (set *addrBitmap) contains(addr uint16) bool {
+	return set[addr>>6]&(1<<(addr&63)) != 0
+}
+
+func (set *addrBitmap) addRange(first, last uint16) {
+	for addr := uint32(first); addr <= uint32(last); addr++ {
+		set.add(uint16(addr))
+	}
+}
+
+func (set *addrBitmap) len() int {
+	n := 0
+	for _, word := range set {
+		n += bits.OnesCount64(word)
+	}
+
+	return n
+}
+
+// GroupAddrSet is a set of group addresses. It uses a bitmap, which makes membership tests cheap.
+// The zero value is an empty set.
+type GroupAddrSet struct {
+	bitmap addrBitmap
+}
+
+// Add an address to the set.
+func (set *GroupAddrSet) Add(addr GroupAddr) {
+	set.bitmap.add(uint16(addr))
+}
+
+// AddRange adds all addresses of the range to the set.
+func (set *GroupAddrSet) AddRange(r GroupAddrRange) {
+	set.bitmap.addRange(uint16(r.First), uint16(r.Last))
+}
+
+// Remove an address from the set.
+func (set *GroupAddrSet) Remove(addr GroupAddr) {
+	set.bitmap.remove(uint16(addr))
+}
+
+// Contains determines whether the address is part of the set.
+func (set *GroupAddrSet) Contains(addr GroupAddr) bool {
+	return set.bitmap.contains(uint16(addr))
+}
+
+// Len returns the number of addresses in the set.
+func (set *GroupAddrSet) Len() int {
+	return set.bitmap.len()
+}
+
+// IndividualAddrSet is a set of individual addresses. It uses a bitmap, which makes membership
+// tests cheap. The zero value is an empty set.
+type IndividualAddrSet struct {
+	bitmap addrBitmap
+}
+
+// Add an address to the set.
+func (set *IndividualAddrSet) Add(addr IndividualAddr) {
+	set.bitmap.add(uint16(addr))
+}
+
+// AddRange adds all addresses of the range to the set.
+func (set *IndividualAddrSet) AddRange(r IndividualAddrRange) {
+	set.bitmap.addRange(uint16(r.First), uint16(r.Last))
+}
+
+// Remove an address from the set.
+func (set *IndividualAddrSet) Remove(addr IndividualAddr) {
+	set.bitmap.remove(uint16(addr))
+}
+
+// Contains determines whether the address is part of the set.
+func (set *IndividualAddrSet) Contains(addr IndividualAddr) bool {
+	return set.bitmap.contains(uint16(addr))
+}
+
+// Len returns the number of addresses in the set.
+func (set *IndividualAddrSet) Len() int {
+	return set.bitmap.len()
+}
+
+// GroupAddrStyle determines how group addresses are formatted.
+type GroupAddrStyle uint8
+
+// These are the group address notations that ETS supports.
+const (
+	// GroupAddrStyle3Level uses main, middle and sub group, e.g. "1/2/3".
+	GroupAddrStyle3Level GroupAddrStyle = iota
+
+	// GroupAddrStyle2Level uses main and sub group, e.g. "1/515".
+	GroupAddrStyle2Level
+
+	// GroupAddrStyleFree uses the raw address, e.g. "2563".
+	GroupAddrStyleFree
+)
+
+// Format generates a string representation in the given notation.
+func (addr GroupAddr) Format(style GroupAddrStyle) string {
+	switch style {
+	case GroupAddrStyle2Level:
+		return fmt.Sprintf("%d/%d", uint8(addr>>11)&0x1F, uint16(addr)&0x7FF)
+
+	case GroupAddrStyleFree:
+		return strconv.Itoa(int(addr))
+	}
+
+	return addr.String()
+}
@@ -0,0 +1,191 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package cemi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// A FrameBuilder assembles L_Data frames. The first invalid setting is reported by Build.
+//
+//	ldata, err := cemi.NewFrameBuilder().
+//		Source(cemi.NewIndividualAddr3(1, 1, 5)).
+//		ToGroup(cemi.NewGroupAddr3(1, 2, 3)).
+//		Priority(cemi.PrioNormal).
+//		Command(cemi.GroupValueWrite, []byte{1}).
+//		Build()
+type FrameBuilder struct {
+	ldata   LData
+	hasDest bool
+	err     error
+}
+
+// NewFrameBuilder creates a builder for a frame with low priority, 6 hops, no repetitions and
+// requested acknowledgement.
+func NewFrameBuilder() *FrameBuilder {
+	return &FrameBuilder{
+		ldata: LData{
+			Control1: Control1NoRepeat | Control1NoSysBroadcast | Control1WantAck | Control1Prio(PrioLow),
+			Control2: Control2Hops(6),
+		},
+	}
+}
+
+// fail records the error if no other error has occurred before.
+func (builder *FrameBuilder) fail(err error) *FrameBuilder {
+	if builder.err == nil {
+		builder.err = err
+	}
+
+	return builder
+}
+
+// Source sets the source address.
+func (builder *FrameBuilder) Source(addr IndividualAddr) *FrameBuilder {
+	builder.ldata.Source = addr
+	return builder
+}
+
+// ToGroup addresses the group.
+func (builder *FrameBuilder) ToGroup(addr GroupAddr) *FrameBuilder {
+	if addr == 0 {
+		return builder.fail(errors.New("group address 0/0/0 is reserved for broadcasts"))
+	}
+
+	builder.ldata.Control2.SetGroupAddr(true)
+	builder.ldata.Control2.SetExtendedFormat(0)
+	builder.ldata.Destination = uint16(addr)
+	builder.hasDest = true
+
+	return builder
+}
+
+// ToIndividual addresses a single device.
+func (builder *FrameBuilder) ToIndividual(addr IndividualAddr) *FrameBuilder {
+	if addr == 0 {
+		return builder.fail(errors.New("individual address 0.0.0 is invalid"))
+	}
+
+	builder.ldata.Control2.SetGroupAddr(false)
+	builder.ldata.Control2.SetExtendedFormat(0)
+	builder.ldata.Destination = uint16(addr)
+	builder.hasDest = true
+
+	return builder
+}
+
+// ToBroadcast addresses all devices using a broadcast. System broadcasts reach devices of all
+// domains on open media.
+func (builder *FrameBuilder) ToBroadcast(system bool) *FrameBuilder {
+	builder.ldata.Control1.SetSysBroadcast(system)
+	builder.ldata.Control2.SetGroupAddr(true)
+	builder.ldata.Control2.SetExtendedFormat(0)
+	builder.ldata.Destination = 0
+	builder.hasDest = true
+
+	return builder
+}
+
+// ToLTE addresses a LTE-HEE destination. Use an LTEZone as destination for geographical
+// addressing.
+func (builder *FrameBuilder) ToLTE(addrType LTEAddrType, dest uint16) *FrameBuilder {
+	if addrType > LTEBroadcast {
+		return builder.fail(fmt.Errorf("invalid LTE address type %d", addrType))
+	}
+
+	builder.ldata.Control2.SetGroupAddr(true)
+	builder.ldata.Control2.SetExtendedFormat(uint8(Control2LTE(addrType) & 15))
+	builder.ldata.Destination = dest
+	builder.hasDest = true
+
+	return builder
+}
+
+// Priority sets the priority.
+func (builder *FrameBuilder) Priority(prio Priority) *FrameBuilder {
+	if prio > PrioLow {
+		return builder.fail(fmt.Errorf("invalid priority %d", prio))
+	}
+
+	builder.ldata.Control1.SetPriority(prio)
+
+	return builder
+}
+
+// Hops sets the hop count, at most 7.
+func (builder *FrameBuilder) Hops(hops uint8) *FrameBuilder {
+	if hops > 7 {
+		return builder.fail(fmt.Errorf("hop count %d exceeds 7", hops))
+	}
+
+	builder.ldata.Control2.SetHops(hops)
+
+	return builder
+}
+
+// Repeat allows or disallows repetitions on the medium.
+func (builder *FrameBuilder) Repeat(repeat bool) *FrameBuilder {
+	builder.ldata.Control1.SetRepeat(repeat)
+	return builder
+}
+
+// WantAck requests an acknowledgement or not.
+func (builder *FrameBuilder) WantAck(ack bool) *FrameBuilder {
+	builder.ldata.Control1.SetWantAck(ack)
+	return builder
+}
+
+// Command sets application data with the given command.
+func (builder *FrameBuilder) Command(command APCI, data []byte) *FrameBuilder {
+	if command > Escape {
+		return builder.fail(fmt.Errorf("invalid APCI %d", command))
+	}
+
+	builder.ldata.Data = &AppData{Command: command, Data: data}
+
+	return builder
+}
+
+// ExtendedCommand sets application data with the given extended command.
+func (builder *FrameBuilder) ExtendedCommand(command ExtendedAPCI, data []byte) *FrameBuilder {
+	if command > 0x3FF {
+		return builder.fail(fmt.Errorf("invalid extended APCI %#x", command))
+	}
+
+	builder.ldata.Data = NewExtendedAppData(command, data)
+
+	return builder
+}
+
+// Transport sets the transport unit.
+func (builder *FrameBuilder) Transport(unit TransportUnit) *FrameBuilder {
+	builder.ldata.Data = unit
+	return builder
+}
+
+// Build validates the settings and returns the frame. Whether the frame is a standard or extended
+// frame is determined automatically.
+func (builder *FrameBuilder) Build() (LData, error) {
+	if builder.err != nil {
+		return LData{}, builder.err
+	}
+
+	if !builder.hasDest {
+		return LData{}, errors.New("frame has no destination")
+	}
+
+	if builder.ldata.Data == nil {
+		return LData{}, errors.New("frame has no transport unit")
+	}
+
+	ldata := builder.ldata
+	ldata.SetFrameType()
+
+	if err := ldata.Validate(0); err != nil {
+		return LData{}, err
+	}
+
+	return ldata, nil
+}
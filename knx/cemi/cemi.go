@@ -6,6 +6,7 @@ package cemi
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/vapourismo/knx-go/knx/util"
 )
@@ -35,6 +36,36 @@ const (
 	// LRawConCode is the message code for L_Raw.con.
 	LRawConCode MessageCode = 0x2F
 
+	// MPropReadReqCode is the message code for M_PropRead.req.
+	MPropReadReqCode MessageCode = 0xFC
+
+	// MPropReadConCode is the message code for M_PropRead.con.
+	MPropReadConCode MessageCode = 0xFB
+
+	// MPropWriteReqCode is the message code for M_PropWrite.req.
+	MPropWriteReqCode MessageCode = 0xF6
+
+	// MPropWriteConCode is the message code for M_PropWrite.con.
+	MPropWriteConCode MessageCode = 0xF5
+
+	// MPropInfoIndCode is the message code for M_PropInfo.ind.
+	MPropInfoIndCode MessageCode = 0xF7
+
+	// MFuncPropCommandReqCode is the message code for M_FuncPropCommand.req.
+	MFuncPropCommandReqCode MessageCode = 0xF8
+
+	// MFuncPropStateReadReqCode is the message code for M_FuncPropStateRead.req.
+	MFuncPropStateReadReqCode MessageCode = 0xF9
+
+	// MFuncPropConCode is the message code for M_FuncPropCommand.con and M_FuncPropStateRead.con.
+	MFuncPropConCode MessageCode = 0xFA
+
+	// MResetReqCode is the message code for M_Reset.req.
+	MResetReqCode MessageCode = 0xF1
+
+	// MResetIndCode is the message code for M_Reset.ind.
+	MResetIndCode MessageCode = 0xF0
+
 	// LPollDataReqCode MessageCode = 0x13
 	// LPollDataConCode MessageCode = 0x25
 )
@@ -63,6 +94,36 @@ func (code MessageCode) String() string {
 	case LRawConCode:
 		return "LRaw.con"
 
+	case MPropReadReqCode:
+		return "MPropRead.req"
+
+	case MPropReadConCode:
+		return "MPropRead.con"
+
+	case MPropWriteReqCode:
+		return "MPropWrite.req"
+
+	case MPropWriteConCode:
+		return "MPropWrite.con"
+
+	case MPropInfoIndCode:
+		return "MPropInfo.ind"
+
+	case MFuncPropCommandReqCode:
+		return "MFuncPropCommand.req"
+
+	case MFuncPropStateReadReqCode:
+		return "MFuncPropStateRead.req"
+
+	case MFuncPropConCode:
+		return "MFuncProp.con"
+
+	case MResetReqCode:
+		return "MReset.req"
+
+	case MResetIndCode:
+		return "MReset.ind"
+
 	default:
 		return fmt.Sprintf("%#x", uint8(code))
 	}
@@ -93,18 +154,34 @@ func (info Info) Pack(buffer []byte) {
 
 // Unpack initializes the structure by parsing the given data.
 func (info *Info) Unpack(data []byte) (n uint, err error) {
-	var length uint8
+	return info.unpack(data, false)
+}
+
+// unpack parses the info segment. If reuse is true, the existing slice is overwritten if its
+// capacity suffices.
+func (info *Info) unpack(data []byte, reuse bool) (n uint, err error) {
+	if len(data) < 1 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	length := data[0]
+	n = 1
 
-	n, err = util.Unpack(data, &length)
-	if err != nil {
-		return
+	if len(data) < int(n)+int(length) {
+		return n, io.ErrUnexpectedEOF
 	}
 
-	if length > 0 {
+	switch {
+	case reuse && cap(*info) >= int(length):
+		*info = (*info)[:length]
+		n += uint(copy(*info, data[n:]))
+
+	case length > 0:
 		buf := make([]byte, length)
 		n += uint(copy(buf, data[n:n+uint(length)]))
 		*info = Info(buf)
-	} else {
+
+	default:
 		*info = nil
 	}
 
@@ -154,14 +231,14 @@ type messageUnpackable interface {
 
 // Unpack a message from a CEMI-encoded frame.
 func Unpack(data []byte, message *Message) (n uint, err error) {
-	var code MessageCode
-
 	// Read header.
-	n, err = util.Unpack(data, (*uint8)(&code))
-	if err != nil {
-		return
+	if len(data) < 1 {
+		return 0, io.ErrUnexpectedEOF
 	}
 
+	code := MessageCode(data[0])
+	n = 1
+
 	var body messageUnpackable
 
 	// Decide which message is appropriate.
@@ -187,6 +264,36 @@ func Unpack(data []byte, message *Message) (n uint, err error) {
 	case LRawIndCode:
 		body = &LRawInd{}
 
+	case MPropReadReqCode:
+		body = &MPropReadReq{}
+
+	case MPropReadConCode:
+		body = &MPropReadCon{}
+
+	case MPropWriteReqCode:
+		body = &MPropWriteReq{}
+
+	case MPropWriteConCode:
+		body = &MPropWriteCon{}
+
+	case MPropInfoIndCode:
+		body = &MPropInfoInd{}
+
+	case MFuncPropCommandReqCode:
+		body = &MFuncPropCommandReq{}
+
+	case MFuncPropStateReadReqCode:
+		body = &MFuncPropStateReadReq{}
+
+	case MFuncPropConCode:
+		body = &MFuncPropCon{}
+
+	case MResetReqCode:
+		body = &MResetReq{}
+
+	case MResetIndCode:
+		body = &MResetInd{}
+
 	default:
 		body = &UnsupportedMessage{Code: code}
 	}
@@ -201,6 +308,33 @@ func Unpack(data []byte, message *Message) (n uint, err error) {
 	return n + m, err
 }
 
+// UnpackInto parses a CEMI-encoded frame like Unpack. If message already points to a L_Data message
+// of the same type, that message, its transport unit and its byte slices are overwritten instead of
+// allocating new ones. Do not use it while the previous contents are still in use elsewhere.
+func UnpackInto(data []byte, message *Message) (uint, error) {
+	if len(data) > 0 && *message != nil && (*message).MessageCode() == MessageCode(data[0]) {
+		var ldata *LData
+
+		switch body := (*message).(type) {
+		case *LDataReq:
+			ldata = &body.LData
+
+		case *LDataCon:
+			ldata = &body.LData
+
+		case *LDataInd:
+			ldata = &body.LData
+		}
+
+		if ldata != nil {
+			n, err := ldata.unpack(data[1:], true)
+			return 1 + n, err
+		}
+	}
+
+	return Unpack(data, message)
+}
+
 // Size returns the size for a CEMI-encoded frame with the given message.
 func Size(message Message) uint {
 	return 1 + message.Size()
@@ -208,5 +342,6 @@ func Size(message Message) uint {
 
 // Pack assembles a CEMI-encoded frame using the given message.
 func Pack(buffer []byte, message Message) {
-	util.PackSome(buffer, uint8(message.MessageCode()), message)
+	buffer[0] = byte(message.MessageCode())
+	message.Pack(buffer[1:])
 }
@@ -6,7 +6,10 @@ package cemi
 import (
 	"bytes"
 	"math/rand"
+	"reflect"
 	"testing"
+
+	"github.com/vapourismo/knx-go/knx/util"
 )
 
 func makeRandInfoSegment() []byte {
@@ -61,3 +64,61 @@ func TestUnpack(t *testing.T) {
 		}
 	}
 }
+
+func makeLDataInd(data ...byte) *LDataInd {
+	return &LDataInd{
+		LData: LData{
+			Control1:    Control1StdFrame | Control1NoRepeat | Control1NoSysBroadcast | Control1Prio(PrioLow),
+			Control2:    Control2GroupAddr | Control2Hops(6),
+			Source:      NewIndividualAddr3(1, 1, 5),
+			Destination: uint16(NewGroupAddr3(1, 2, 3)),
+			Data:        &AppData{Command: GroupValueWrite, Data: data},
+		},
+	}
+}
+
+func TestUnpackInto(t *testing.T) {
+	var msg Message = makeLDataInd(0, 1, 2, 3)
+	app := msg.(*LDataInd).Data.(*AppData)
+
+	expected := makeLDataInd(0, 0x2A)
+	buffer := make([]byte, Size(expected))
+	Pack(buffer, expected)
+
+	if _, err := UnpackInto(buffer, &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(msg, Message(expected)) {
+		t.Errorf("Mismatch:\n%+v\n%+v", msg, expected)
+	}
+
+	if msg.(*LDataInd).Data.(*AppData) != app {
+		t.Error("Transport unit has not been reused")
+	}
+
+	// Different message types must not be reused.
+	msg = &LDataReq{}
+	if _, err := UnpackInto(buffer, &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := msg.(*LDataInd); !ok {
+		t.Errorf("Unexpected message type %T", msg)
+	}
+}
+
+func BenchmarkUnpackInto(b *testing.B) {
+	b.ReportAllocs()
+
+	buffer := util.AppendPack(nil, makeLDataInd(0, 0x13, 0x37))
+	buffer = append([]byte{byte(LDataIndCode)}, buffer...)
+
+	var msg Message
+
+	for i := 0; i < b.N; i++ {
+		if _, err := UnpackInto(buffer, &msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
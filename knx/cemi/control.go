@@ -48,6 +48,75 @@ func Control1Prio(prio Priority) ControlField1 {
 	return ControlField1(prio&3) << 2
 }
 
+// setFlag sets or clears the flag.
+func (ctrl1 *ControlField1) setFlag(flag ControlField1, set bool) {
+	if set {
+		*ctrl1 |= flag
+	} else {
+		*ctrl1 &^= flag
+	}
+}
+
+// StdFrame determines if the frame is a standard frame.
+func (ctrl1 ControlField1) StdFrame() bool {
+	return ctrl1&Control1StdFrame != 0
+}
+
+// SetStdFrame marks the frame as standard frame or extended frame.
+func (ctrl1 *ControlField1) SetStdFrame(std bool) {
+	ctrl1.setFlag(Control1StdFrame, std)
+}
+
+// Repeat determines if the frame may be repeated on the medium.
+func (ctrl1 ControlField1) Repeat() bool {
+	return ctrl1&Control1NoRepeat == 0
+}
+
+// SetRepeat allows or disallows repetitions on the medium.
+func (ctrl1 *ControlField1) SetRepeat(repeat bool) {
+	ctrl1.setFlag(Control1NoRepeat, !repeat)
+}
+
+// SysBroadcast determines if a broadcast frame is transmitted as system broadcast.
+func (ctrl1 ControlField1) SysBroadcast() bool {
+	return ctrl1&Control1NoSysBroadcast == 0
+}
+
+// SetSysBroadcast selects system broadcast or normal broadcast.
+func (ctrl1 *ControlField1) SetSysBroadcast(sys bool) {
+	ctrl1.setFlag(Control1NoSysBroadcast, !sys)
+}
+
+// Priority retrieves the priority.
+func (ctrl1 ControlField1) Priority() Priority {
+	return Priority(ctrl1>>2) & 3
+}
+
+// SetPriority sets the priority.
+func (ctrl1 *ControlField1) SetPriority(prio Priority) {
+	*ctrl1 = *ctrl1&^Control1Prio(3) | Control1Prio(prio)
+}
+
+// WantAck determines if an acknowledgement is requested.
+func (ctrl1 ControlField1) WantAck() bool {
+	return ctrl1&Control1WantAck != 0
+}
+
+// SetWantAck requests an acknowledgement or not.
+func (ctrl1 *ControlField1) SetWantAck(ack bool) {
+	ctrl1.setFlag(Control1WantAck, ack)
+}
+
+// HasError determines if a confirmation indicates an error.
+func (ctrl1 ControlField1) HasError() bool {
+	return ctrl1&Control1HasError != 0
+}
+
+// SetHasError sets or clears the error flag.
+func (ctrl1 *ControlField1) SetHasError(hasError bool) {
+	ctrl1.setFlag(Control1HasError, hasError)
+}
+
 // ControlField2 contains various control information.
 type ControlField2 uint8
 
@@ -56,9 +125,46 @@ func (ctrl2 ControlField2) IsGroupAddr() bool {
 	return ctrl2&Control2GroupAddr == Control2GroupAddr
 }
 
+// SetGroupAddr determines whether the destination address is a group address or an individual
+// address.
+func (ctrl2 *ControlField2) SetGroupAddr(group bool) {
+	if group {
+		*ctrl2 |= Control2GroupAddr
+	} else {
+		*ctrl2 &^= Control2GroupAddr
+	}
+}
+
 // Hops retrieves the number of hops.
 func (ctrl2 ControlField2) Hops() uint8 {
-	return uint8(ctrl2>>7) & 7
+	return uint8(ctrl2>>4) & 7
+}
+
+// SetHops sets the number of hops. Values above 7 are capped.
+func (ctrl2 *ControlField2) SetHops(hops uint8) {
+	*ctrl2 = *ctrl2&^Control2Hops(7) | Control2Hops(hops)
+}
+
+// ExtendedFormat retrieves the extended frame format. It is 0 for standard group and individual
+// addressing.
+func (ctrl2 ControlField2) ExtendedFormat() uint8 {
+	return uint8(ctrl2) & 15
+}
+
+// SetExtendedFormat sets the extended frame format. Only the lower 4 bits are used.
+func (ctrl2 *ControlField2) SetExtendedFormat(format uint8) {
+	*ctrl2 = *ctrl2&^15 | ControlField2(format&15)
+}
+
+// IsLTE determines if the frame uses LTE-HEE extended group addressing.
+func (ctrl2 ControlField2) IsLTE() bool {
+	return ctrl2&12 == Control2LTEFrame
+}
+
+// LTEAddrType retrieves the type of the LTE-HEE extended address. It is only meaningful if IsLTE
+// returns true.
+func (ctrl2 ControlField2) LTEAddrType() LTEAddrType {
+	return LTEAddrType(ctrl2 & 3)
 }
 
 const (
@@ -78,3 +184,9 @@ func Control2Hops(hops uint8) ControlField2 {
 
 	return ControlField2(hops&7) << 4
 }
+
+// Control2LTE generates the control field 2 flags for a LTE-HEE frame with the given address type.
+// LTE-HEE frames always carry a group address, therefore Control2GroupAddr is included.
+func Control2LTE(addrType LTEAddrType) ControlField2 {
+	return Control2GroupAddr | Control2LTEFrame | ControlField2(addrType&3)
+}
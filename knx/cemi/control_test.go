@@ -0,0 +1,182 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package cemi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vapourismo/knx-go/knx/util"
+)
+
+func TestControlField1(t *testing.T) {
+	flags := []struct {
+		name string
+		get  func(ControlField1) bool
+		set  func(*ControlField1, bool)
+		flag ControlField1
+		set1 bool
+	}{
+		{"StdFrame", ControlField1.StdFrame, (*ControlField1).SetStdFrame, Control1StdFrame, true},
+		{"Repeat", ControlField1.Repeat, (*ControlField1).SetRepeat, Control1NoRepeat, false},
+		{"SysBroadcast", ControlField1.SysBroadcast, (*ControlField1).SetSysBroadcast, Control1NoSysBroadcast, false},
+		{"WantAck", ControlField1.WantAck, (*ControlField1).SetWantAck, Control1WantAck, true},
+		{"HasError", ControlField1.HasError, (*ControlField1).SetHasError, Control1HasError, true},
+	}
+
+	for _, f := range flags {
+		for _, value := range []bool{true, false} {
+			ctrl1 := Control1Prio(PrioUrgent)
+			f.set(&ctrl1, value)
+
+			if f.get(ctrl1) != value {
+				t.Errorf("%s: expected %v", f.name, value)
+			}
+
+			if (ctrl1&f.flag != 0) != (value == f.set1) {
+				t.Errorf("%s: unexpected bits %08b", f.name, ctrl1)
+			}
+
+			if ctrl1.Priority() != PrioUrgent {
+				t.Errorf("%s: priority has been modified", f.name)
+			}
+		}
+	}
+
+	for prio := PrioSystem; prio <= PrioLow; prio++ {
+		ctrl1 := ControlField1(0xFF)
+		ctrl1.SetPriority(prio)
+
+		if ctrl1.Priority() != prio || ctrl1 != 0xF3|Control1Prio(prio) {
+			t.Errorf("Unexpected control field %08b for priority %v", ctrl1, prio)
+		}
+	}
+}
+
+func TestControlField2(t *testing.T) {
+	for hops := uint8(0); hops <= 7; hops++ {
+		ctrl2 := Control2GroupAddr | Control2LTEFrame
+		ctrl2.SetHops(hops)
+
+		if ctrl2.Hops() != hops || Control2Hops(hops).Hops() != hops {
+			t.Errorf("Unexpected hop count %d, expected %d", ctrl2.Hops(), hops)
+		}
+
+		if !ctrl2.IsGroupAddr() || ctrl2.ExtendedFormat() != 4 {
+			t.Errorf("Other fields have been modified: %08b", ctrl2)
+		}
+	}
+
+	ctrl2 := Control2Hops(6)
+	ctrl2.SetHops(9)
+
+	if ctrl2.Hops() != 7 {
+		t.Errorf("Hop count should be capped, got %d", ctrl2.Hops())
+	}
+
+	ctrl2.SetGroupAddr(true)
+	if !ctrl2.IsGroupAddr() || ctrl2.Hops() != 7 {
+		t.Errorf("Unexpected control field %08b", ctrl2)
+	}
+
+	ctrl2.SetGroupAddr(false)
+	if ctrl2.IsGroupAddr() {
+		t.Errorf("Unexpected control field %08b", ctrl2)
+	}
+
+	for format := uint8(0); format <= 15; format++ {
+		ctrl2.SetExtendedFormat(format)
+
+		if ctrl2.ExtendedFormat() != format || ctrl2.Hops() != 7 {
+			t.Errorf("Unexpected control field %08b for format %d", ctrl2, format)
+		}
+	}
+
+	for addrType := LTEGeographical; addrType <= LTEBroadcast; addrType++ {
+		ctrl2 := Control2LTE(addrType)
+
+		if !ctrl2.IsLTE() || ctrl2.LTEAddrType() != addrType {
+			t.Errorf("Unexpected control field %08b for LTE address type %v", ctrl2, addrType)
+		}
+	}
+}
+
+func TestFrameBuilder(t *testing.T) {
+	ldata, err := NewFrameBuilder().
+		Source(NewIndividualAddr3(1, 1, 5)).
+		ToGroup(NewGroupAddr3(1, 2, 3)).
+		Priority(PrioNormal).
+		Hops(5).
+		WantAck(false).
+		Command(GroupValueWrite, []byte{1}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := LData{
+		Control1:    Control1StdFrame | Control1NoRepeat | Control1NoSysBroadcast | Control1Prio(PrioNormal),
+		Control2:    Control2GroupAddr | Control2Hops(5),
+		Source:      NewIndividualAddr3(1, 1, 5),
+		Destination: uint16(NewGroupAddr3(1, 2, 3)),
+		Data:        &AppData{Command: GroupValueWrite, Data: []byte{1}},
+	}
+
+	if !reflect.DeepEqual(ldata, expected) {
+		t.Errorf("Mismatch:\n%v\n%v", &ldata, &expected)
+	}
+
+	var unpacked LData
+	if _, err := unpacked.Unpack(util.AllocAndPack(&ldata)); err != nil {
+		t.Fatal(err)
+	}
+
+	if unpacked.Control1.Priority() != PrioNormal || unpacked.Control2.Hops() != 5 ||
+		unpacked.Control1.WantAck() || unpacked.Control1.Repeat() || !unpacked.Control1.StdFrame() {
+		t.Errorf("Unexpected fields after round trip: %v", &unpacked)
+	}
+
+	ldata, err = NewFrameBuilder().
+		ToBroadcast(true).
+		Priority(PrioSystem).
+		Command(IndividualAddrRequest, nil).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ldata.Control1.SysBroadcast() || !ldata.Control2.IsGroupAddr() || ldata.Destination != 0 {
+		t.Errorf("Unexpected broadcast frame %v", &ldata)
+	}
+
+	ldata, err = NewFrameBuilder().
+		ToIndividual(NewIndividualAddr3(1, 1, 10)).
+		ExtendedCommand(PropertyValueRead, make([]byte, 20)).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ldata.Control1.StdFrame() || ldata.Control2.IsGroupAddr() {
+		t.Errorf("Unexpected individual frame %v", &ldata)
+	}
+
+	invalid := []*FrameBuilder{
+		NewFrameBuilder().Command(GroupValueRead, nil),
+		NewFrameBuilder().ToGroup(NewGroupAddr3(1, 2, 3)),
+		NewFrameBuilder().ToGroup(0).Command(GroupValueRead, nil),
+		NewFrameBuilder().ToIndividual(0).Command(GroupValueRead, nil),
+		NewFrameBuilder().ToGroup(1).Hops(8).Command(GroupValueRead, nil),
+		NewFrameBuilder().ToGroup(1).Priority(4).Command(GroupValueRead, nil),
+		NewFrameBuilder().ToGroup(1).Command(16, nil),
+		NewFrameBuilder().ToLTE(4, 0).Command(GroupValueRead, nil),
+		NewFrameBuilder().ToGroup(1).Command(GroupValueWrite, make([]byte, 255)),
+	}
+
+	for i, builder := range invalid {
+		if _, err := builder.Build(); err == nil {
+			t.Errorf("Builder %d should fail", i)
+		}
+	}
+}
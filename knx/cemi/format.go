@@ -0,0 +1,290 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package cemi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// String generates the name of the command.
+func (apci APCI) String() string {
+	switch apci {
+	case GroupValueRead:
+		return "GroupValueRead"
+
+	case GroupValueResponse:
+		return "GroupValueResponse"
+
+	case GroupValueWrite:
+		return "GroupValueWrite"
+
+	case IndividualAddrWrite:
+		return "IndividualAddrWrite"
+
+	case IndividualAddrRequest:
+		return "IndividualAddrRead"
+
+	case IndividualAddrResponse:
+		return "IndividualAddrResponse"
+
+	case AdcRead:
+		return "AdcRead"
+
+	case AdcResponse:
+		return "AdcResponse"
+
+	case MemoryRead:
+		return "MemoryRead"
+
+	case MemoryResponse:
+		return "MemoryResponse"
+
+	case MemoryWrite:
+		return "MemoryWrite"
+
+	case UserMessage:
+		return "UserMessage"
+
+	case MaskVersionRead:
+		return "DeviceDescriptorRead"
+
+	case MaskVersionResponse:
+		return "DeviceDescriptorResponse"
+
+	case Restart:
+		return "Restart"
+
+	case Escape:
+		return "Escape"
+
+	default:
+		return fmt.Sprintf("APCI(%#x)", uint8(apci))
+	}
+}
+
+// extendedAPCINames contains the names of known extended commands.
+var extendedAPCINames = map[ExtendedAPCI]string{
+	MemoryExtendedWrite:                "MemoryExtendedWrite",
+	MemoryExtendedWriteResponse:        "MemoryExtendedWriteResponse",
+	MemoryExtendedRead:                 "MemoryExtendedRead",
+	MemoryExtendedReadResponse:         "MemoryExtendedReadResponse",
+	RestartMasterReset:                 "RestartMasterReset",
+	RestartResponse:                    "RestartResponse",
+	PropertyValueRead:                  "PropertyValueRead",
+	PropertyValueResponse:              "PropertyValueResponse",
+	PropertyValueWrite:                 "PropertyValueWrite",
+	PropertyDescriptionRead:            "PropertyDescriptionRead",
+	PropertyDescriptionResponse:        "PropertyDescriptionResponse",
+	NetworkParameterRead:               "NetworkParameterRead",
+	NetworkParameterResponse:           "NetworkParameterResponse",
+	IndividualAddrSerialNumberRead:     "IndividualAddrSerialNumberRead",
+	IndividualAddrSerialNumberResponse: "IndividualAddrSerialNumberResponse",
+	IndividualAddrSerialNumberWrite:    "IndividualAddrSerialNumberWrite",
+	DomainAddressWrite:                 "DomainAddressWrite",
+	DomainAddressRead:                  "DomainAddressRead",
+	DomainAddressResponse:              "DomainAddressResponse",
+	DomainAddressSelectiveRead:         "DomainAddressSelectiveRead",
+	NetworkParameterWrite:              "NetworkParameterWrite",
+}
+
+// String generates the name of the command.
+func (apci ExtendedAPCI) String() string {
+	if name, ok := extendedAPCINames[apci]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("APCI(%#03x)", uint16(apci))
+}
+
+// String generates a string representation.
+func (prio Priority) String() string {
+	switch prio {
+	case PrioSystem:
+		return "system"
+
+	case PrioNormal:
+		return "normal"
+
+	case PrioUrgent:
+		return "urgent"
+
+	case PrioLow:
+		return "low"
+
+	default:
+		return fmt.Sprintf("%d", uint8(prio))
+	}
+}
+
+// A ValueFormatter converts the data of a group telegram to a readable value, usually by decoding
+// the datapoint type associated with the group address. It returns false if it cannot do so, in
+// which case the raw data is shown.
+type ValueFormatter func(dest GroupAddr, data []byte) (string, bool)
+
+// formatBytes generates the "$01 $02" representation of the bytes.
+func formatBytes(builder *strings.Builder, data []byte) {
+	for _, b := range data {
+		fmt.Fprintf(builder, " $%02X", b)
+	}
+}
+
+// formatTransportUnit appends the command and its data.
+func formatTransportUnit(builder *strings.Builder, ldata *LData, values ValueFormatter) {
+	switch unit := ldata.Data.(type) {
+	case *AppData:
+		if unit.Command.IsGroupCommand() {
+			builder.WriteString(unit.Command.String())
+
+			if unit.Command == GroupValueRead {
+				break
+			}
+
+			if values != nil && ldata.Control2.IsGroupAddr() {
+				if value, ok := values(GroupAddr(ldata.Destination), unit.Data); ok {
+					builder.WriteString(" ")
+					builder.WriteString(value)
+					break
+				}
+			}
+
+			// Short values are contained in the lower 6 bits of the first byte.
+			if len(unit.Data) == 1 {
+				formatBytes(builder, unit.Data)
+			} else if len(unit.Data) > 1 {
+				formatBytes(builder, unit.Data[1:])
+			}
+		} else if name, ok := extendedAPCINames[unit.ExtendedCommand()]; ok && len(unit.Data) > 0 {
+			builder.WriteString(name)
+			formatBytes(builder, unit.Data[1:])
+		} else {
+			builder.WriteString(unit.Command.String())
+			formatBytes(builder, unit.Data)
+		}
+
+		if unit.Numbered {
+			fmt.Fprintf(builder, " seq=%d", unit.SeqNumber)
+		}
+
+	case *ControlData:
+		switch unit.Command {
+		case 0:
+			builder.WriteString("T_Connect")
+
+		case 1:
+			builder.WriteString("T_Disconnect")
+
+		case 2:
+			builder.WriteString("T_ACK")
+
+		case 3:
+			builder.WriteString("T_NAK")
+		}
+
+		if unit.Numbered {
+			fmt.Fprintf(builder, " seq=%d", unit.SeqNumber)
+		}
+
+	case nil:
+		builder.WriteString("<no data>")
+
+	default:
+		fmt.Fprintf(builder, "%T", unit)
+	}
+}
+
+// FormatLData generates a line similar to those of the ETS bus monitor, for example
+// "1.1.5 -> 1/2/3 GroupValueWrite $01 prio=low hops=6 ack". The value formatter is optional.
+func FormatLData(ldata *LData, values ValueFormatter) string {
+	builder := &strings.Builder{}
+
+	builder.WriteString(ldata.Source.String())
+	builder.WriteString(" -> ")
+
+	switch {
+	case ldata.Control2.IsLTE():
+		fmt.Fprintf(builder, "lte:%s:", ldata.Control2.LTEAddrType())
+
+		if ldata.Control2.LTEAddrType() == LTEGeographical {
+			builder.WriteString(LTEZone(ldata.Destination).String())
+		} else {
+			fmt.Fprintf(builder, "%#04x", ldata.Destination)
+		}
+
+	case ldata.Control2.IsGroupAddr():
+		builder.WriteString(GroupAddr(ldata.Destination).String())
+
+	default:
+		builder.WriteString(IndividualAddr(ldata.Destination).String())
+	}
+
+	builder.WriteString(" ")
+	formatTransportUnit(builder, ldata, values)
+
+	fmt.Fprintf(builder, " prio=%s hops=%d", ldata.Control1.Priority(), ldata.Control2.Hops())
+
+	if !ldata.Control1.StdFrame() {
+		builder.WriteString(" ext")
+	}
+
+	if ldata.Control1.Repeat() {
+		builder.WriteString(" repeat")
+	}
+
+	// The flag only has a meaning for broadcasts.
+	if ldata.Control2.IsGroupAddr() && ldata.Destination == 0 && ldata.Control1.SysBroadcast() {
+		builder.WriteString(" sysbcast")
+	}
+
+	if ldata.Control1.WantAck() {
+		builder.WriteString(" ack")
+	}
+
+	if ldata.Control1.HasError() {
+		builder.WriteString(" error")
+	}
+
+	return builder.String()
+}
+
+// FormatMessage generates a readable line for the message. L_Data frames are formatted using
+// FormatLData, other messages are shown with their name and size.
+func FormatMessage(message Message, values ValueFormatter) string {
+	switch msg := message.(type) {
+	case *LDataReq:
+		return msg.MessageCode().String() + " " + FormatLData(&msg.LData, values)
+
+	case *LDataCon:
+		return msg.MessageCode().String() + " " + FormatLData(&msg.LData, values)
+
+	case *LDataInd:
+		return msg.MessageCode().String() + " " + FormatLData(&msg.LData, values)
+
+	case nil:
+		return "<nil>"
+
+	default:
+		return fmt.Sprintf("%s (%d bytes)", message.MessageCode(), message.Size())
+	}
+}
+
+// String generates a readable representation of the frame.
+func (ldata *LData) String() string {
+	return FormatLData(ldata, nil)
+}
+
+// String generates a readable representation of the message.
+func (req *LDataReq) String() string {
+	return FormatMessage(req, nil)
+}
+
+// String generates a readable representation of the message.
+func (con *LDataCon) String() string {
+	return FormatMessage(con, nil)
+}
+
+// String generates a readable representation of the message.
+func (ind *LDataInd) String() string {
+	return FormatMessage(ind, nil)
+}
@@ -0,0 +1,111 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package cemi
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFormatLData(t *testing.T) {
+	source := NewIndividualAddr3(1, 1, 5)
+	control1 := Control1StdFrame | Control1NoRepeat | Control1NoSysBroadcast | Control1WantAck | Control1Prio(PrioLow)
+
+	cases := []struct {
+		ldata    LData
+		expected string
+	}{
+		{
+			LData{
+				Control1:    control1,
+				Control2:    Control2GroupAddr | Control2Hops(6),
+				Source:      source,
+				Destination: uint16(NewGroupAddr3(1, 2, 3)),
+				Data:        &AppData{Command: GroupValueWrite, Data: []byte{1}},
+			},
+			"1.1.5 -> 1/2/3 GroupValueWrite $01 prio=low hops=6 ack",
+		},
+		{
+			LData{
+				Control1:    Control1StdFrame | Control1Prio(PrioSystem),
+				Control2:    Control2GroupAddr | Control2Hops(5),
+				Source:      source,
+				Destination: uint16(NewGroupAddr3(1, 2, 3)),
+				Data:        &AppData{Command: GroupValueResponse, Data: []byte{0, 0x0C, 0x1A}},
+			},
+			"1.1.5 -> 1/2/3 GroupValueResponse $0C $1A prio=system hops=5 repeat",
+		},
+		{
+			LData{
+				Control1:    Control1StdFrame | Control1NoRepeat | Control1Prio(PrioSystem),
+				Control2:    Control2GroupAddr | Control2Hops(6),
+				Source:      source,
+				Destination: 0,
+				Data:        &AppData{Command: IndividualAddrRequest},
+			},
+			"1.1.5 -> 0/0/0 IndividualAddrRead prio=system hops=6 sysbcast",
+		},
+		{
+			LData{
+				Control1:    control1 &^ Control1NoSysBroadcast,
+				Control2:    Control2Hops(6),
+				Source:      source,
+				Destination: uint16(NewIndividualAddr3(1, 1, 10)),
+				Data:        &ControlData{Command: 0},
+			},
+			"1.1.5 -> 1.1.10 T_Connect prio=low hops=6 ack",
+		},
+		{
+			LData{
+				Control1:    control1,
+				Control2:    Control2Hops(6),
+				Source:      source,
+				Destination: uint16(NewIndividualAddr3(1, 1, 10)),
+				Data:        NewExtendedAppData(PropertyValueRead, []byte{0, 14, 0x10, 0x01}),
+			},
+			"1.1.5 -> 1.1.10 PropertyValueRead $00 $0E $10 $01 prio=low hops=6 ack",
+		},
+		{
+			LData{
+				Control1:    control1,
+				Control2:    Control2Hops(6),
+				Source:      source,
+				Destination: uint16(NewIndividualAddr3(1, 1, 10)),
+				Data:        &ControlData{Numbered: true, SeqNumber: 2, Command: 2},
+			},
+			"1.1.5 -> 1.1.10 T_ACK seq=2 prio=low hops=6 ack",
+		},
+		{
+			LData{
+				Control1:    control1 &^ Control1StdFrame,
+				Control2:    Control2LTE(LTEGeographical) | Control2Hops(6),
+				Source:      source,
+				Destination: uint16(NewLTEZone(1, 2, 3)),
+				Data:        &AppData{Command: GroupValueRead},
+			},
+			"1.1.5 -> lte:Geographical:1/2/3 GroupValueRead prio=low hops=6 ext ack",
+		},
+	}
+
+	for _, c := range cases {
+		if line := FormatLData(&c.ldata, nil); line != c.expected {
+			t.Errorf("Unexpected line:\n%s\n%s", line, c.expected)
+		}
+	}
+
+	values := func(dest GroupAddr, data []byte) (string, bool) {
+		return fmt.Sprintf("on(%v)", dest), true
+	}
+
+	ind := &LDataInd{LData: cases[0].ldata}
+	expected := "LData.ind 1.1.5 -> 1/2/3 GroupValueWrite on(1/2/3) prio=low hops=6 ack"
+
+	if line := FormatMessage(ind, values); line != expected {
+		t.Errorf("Unexpected line:\n%s\n%s", line, expected)
+	}
+
+	if line := fmt.Sprintf("%v", ind); line != "LData.ind "+cases[0].expected {
+		t.Errorf("Unexpected string representation: %s", line)
+	}
+}
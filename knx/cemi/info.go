@@ -0,0 +1,612 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package cemi
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/util"
+)
+
+// InfoType identifies the type of an entry in the additional info segment.
+type InfoType uint8
+
+// These are known additional info types.
+const (
+	InfoPLMedium                  InfoType = 0x01
+	InfoRFMedium                  InfoType = 0x02
+	InfoBusmonitorStatus          InfoType = 0x03
+	InfoRelativeTimestamp         InfoType = 0x04
+	InfoTimeDelay                 InfoType = 0x05
+	InfoExtendedRelativeTimestamp InfoType = 0x06
+	InfoBiBat                     InfoType = 0x07
+	InfoRFMulti                   InfoType = 0x08
+	InfoPreamblePostamble         InfoType = 0x09
+	InfoRFFastAck                 InfoType = 0x0A
+	InfoManufacturer              InfoType = 0xFE
+)
+
+// String converts the info type to a string.
+func (typ InfoType) String() string {
+	switch typ {
+	case InfoPLMedium:
+		return "PL medium"
+
+	case InfoRFMedium:
+		return "RF medium"
+
+	case InfoBusmonitorStatus:
+		return "Busmonitor status"
+
+	case InfoRelativeTimestamp:
+		return "Relative timestamp"
+
+	case InfoTimeDelay:
+		return "Time delay"
+
+	case InfoExtendedRelativeTimestamp:
+		return "Extended relative timestamp"
+
+	case InfoBiBat:
+		return "BiBat"
+
+	case InfoRFMulti:
+		return "RF multi"
+
+	case InfoPreamblePostamble:
+		return "Preamble/postamble"
+
+	case InfoRFFastAck:
+		return "RF fast ACK"
+
+	case InfoManufacturer:
+		return "Manufacturer specific"
+
+	default:
+		return fmt.Sprintf("%#x", uint8(typ))
+	}
+}
+
+// An InfoEntry is an entry in the additional info segment. It packs to the entry data without
+// the type and length header.
+type InfoEntry interface {
+	util.Packable
+	InfoType() InfoType
+}
+
+type infoEntryUnpackable interface {
+	util.Unpackable
+	InfoEntry
+}
+
+// NewInfo assembles the additional info segment from the given entries.
+func NewInfo(entries ...InfoEntry) Info {
+	var size uint
+	for _, entry := range entries {
+		size += 2 + entry.Size()
+	}
+
+	buffer := make([]byte, size)
+
+	var offset uint
+	for _, entry := range entries {
+		buffer[offset] = byte(entry.InfoType())
+		buffer[offset+1] = byte(entry.Size())
+		entry.Pack(buffer[offset+2:])
+
+		offset += 2 + entry.Size()
+	}
+
+	return Info(buffer)
+}
+
+// Entries parses the additional info segment. Entries of unknown type are represented as
+// UnknownInfo.
+func (info Info) Entries() ([]InfoEntry, error) {
+	var entries []InfoEntry
+
+	for data := []byte(info); len(data) > 0; {
+		if len(data) < 2 || len(data) < 2+int(data[1]) {
+			return entries, io.ErrUnexpectedEOF
+		}
+
+		typ := InfoType(data[0])
+		body := data[2 : 2+int(data[1])]
+
+		var entry infoEntryUnpackable
+
+		switch typ {
+		case InfoPLMedium:
+			entry = &PLMediumInfo{}
+
+		case InfoRFMedium:
+			entry = &RFMediumInfo{}
+
+		case InfoBusmonitorStatus:
+			entry = &BusmonitorInfo{}
+
+		case InfoRelativeTimestamp:
+			entry = new(RelativeTimestamp)
+
+		case InfoTimeDelay:
+			entry = new(TimeDelay)
+
+		case InfoExtendedRelativeTimestamp:
+			entry = new(ExtendedRelativeTimestamp)
+
+		case InfoBiBat:
+			entry = &BiBatInfo{}
+
+		case InfoRFMulti:
+			entry = &RFMultiInfo{}
+
+		case InfoPreamblePostamble:
+			entry = &PreamblePostambleInfo{}
+
+		case InfoRFFastAck:
+			entry = &RFFastAckInfo{}
+
+		case InfoManufacturer:
+			entry = &ManufacturerInfo{}
+
+		default:
+			entry = &UnknownInfo{Type: typ}
+		}
+
+		n, err := entry.Unpack(body)
+		if err != nil {
+			return entries, fmt.Errorf("invalid %v info: %v", typ, err)
+		}
+
+		if n != uint(len(body)) {
+			return entries, fmt.Errorf("invalid %v info length %d", typ, len(body))
+		}
+
+		entries = append(entries, entry)
+		data = data[2+len(body):]
+	}
+
+	return entries, nil
+}
+
+// PLMediumInfo is the additional info for frames on a powerline medium.
+type PLMediumInfo struct {
+	DomainAddress uint16
+}
+
+// InfoType returns the additional info type.
+func (PLMediumInfo) InfoType() InfoType {
+	return InfoPLMedium
+}
+
+// Size returns the packed size.
+func (PLMediumInfo) Size() uint {
+	return 2
+}
+
+// Pack the entry into the buffer.
+func (pl *PLMediumInfo) Pack(buffer []byte) {
+	util.PackUInt16(buffer, pl.DomainAddress)
+}
+
+// Unpack initializes the structure by parsing the given data.
+func (pl *PLMediumInfo) Unpack(data []byte) (uint, error) {
+	return util.UnpackUInt16(data, &pl.DomainAddress)
+}
+
+// SignalStrength is a received signal strength on an RF medium.
+type SignalStrength uint8
+
+// These are known signal strengths.
+const (
+	SignalVoid   SignalStrength = 0
+	SignalWeak   SignalStrength = 1
+	SignalMedium SignalStrength = 2
+	SignalStrong SignalStrength = 3
+)
+
+// String converts the signal strength to a string.
+func (rss SignalStrength) String() string {
+	switch rss {
+	case SignalVoid:
+		return "void"
+
+	case SignalWeak:
+		return "weak"
+
+	case SignalMedium:
+		return "medium"
+
+	case SignalStrong:
+		return "strong"
+
+	default:
+		return fmt.Sprintf("%#x", uint8(rss))
+	}
+}
+
+// RFMediumInfo is the additional info for frames on an RF medium.
+type RFMediumInfo struct {
+	RSS              SignalStrength
+	RetransmitterRSS SignalStrength
+
+	// Set if the battery state of the device is OK, cleared if it is low
+	BatteryOK bool
+
+	Unidirectional bool
+
+	// Serial number of the device, or the domain address for frames in domain mode
+	SerialNumber SerialNumber
+
+	// Link layer frame number
+	LFN uint8
+}
+
+// InfoType returns the additional info type.
+func (RFMediumInfo) InfoType() InfoType {
+	return InfoRFMedium
+}
+
+// Size returns the packed size.
+func (RFMediumInfo) Size() uint {
+	return 8
+}
+
+// Pack the entry into the buffer.
+func (rf *RFMediumInfo) Pack(buffer []byte) {
+	info := byte(rf.RSS&3)<<4 | byte(rf.RetransmitterRSS&3)<<2
+
+	if rf.BatteryOK {
+		info |= 1 << 1
+	}
+
+	if rf.Unidirectional {
+		info |= 1
+	}
+
+	buffer[0] = info
+	copy(buffer[1:], rf.SerialNumber[:])
+	buffer[7] = rf.LFN
+}
+
+// Unpack initializes the structure by parsing the given data.
+func (rf *RFMediumInfo) Unpack(data []byte) (n uint, err error) {
+	if len(data) < 8 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	info := data[0]
+	copy(rf.SerialNumber[:], data[1:7])
+	rf.LFN = data[7]
+	n = 8
+
+	rf.RSS = SignalStrength(info>>4) & 3
+	rf.RetransmitterRSS = SignalStrength(info>>2) & 3
+	rf.BatteryOK = info&(1<<1) != 0
+	rf.Unidirectional = info&1 != 0
+
+	return
+}
+
+// BusmonitorInfo is the status of a frame received in busmonitor mode.
+type BusmonitorInfo struct {
+	FrameError     bool
+	BitError       bool
+	ParityError    bool
+	Overflow       bool
+	Lost           bool
+	SequenceNumber uint8
+}
+
+// InfoType returns the additional info type.
+func (BusmonitorInfo) InfoType() InfoType {
+	return InfoBusmonitorStatus
+}
+
+// Size returns the packed size.
+func (BusmonitorInfo) Size() uint {
+	return 1
+}
+
+// Pack the entry into the buffer.
+func (bm *BusmonitorInfo) Pack(buffer []byte) {
+	status := bm.SequenceNumber & 7
+
+	for i, flag := range []bool{bm.Lost, bm.Overflow, bm.ParityError, bm.BitError, bm.FrameError} {
+		if flag {
+			status |= 1 << (3 + uint(i))
+		}
+	}
+
+	buffer[0] = status
+}
+
+// Unpack initializes the structure by parsing the given data.
+func (bm *BusmonitorInfo) Unpack(data []byte) (uint, error) {
+	if len(data) < 1 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	bm.FrameError = data[0]&(1<<7) != 0
+	bm.BitError = data[0]&(1<<6) != 0
+	bm.ParityError = data[0]&(1<<5) != 0
+	bm.Overflow = data[0]&(1<<4) != 0
+	bm.Lost = data[0]&(1<<3) != 0
+	bm.SequenceNumber = data[0] & 7
+
+	return 1, nil
+}
+
+// RelativeTimestamp is a timestamp in milliseconds, relative to a free-running 16-bit counter.
+type RelativeTimestamp uint16
+
+// InfoType returns the additional info type.
+func (RelativeTimestamp) InfoType() InfoType {
+	return InfoRelativeTimestamp
+}
+
+// Size returns the packed size.
+func (RelativeTimestamp) Size() uint {
+	return 2
+}
+
+// Pack the entry into the buffer.
+func (ts RelativeTimestamp) Pack(buffer []byte) {
+	util.PackUInt16(buffer, uint16(ts))
+}
+
+// Unpack initializes the value by parsing the given data.
+func (ts *RelativeTimestamp) Unpack(data []byte) (uint, error) {
+	return util.UnpackUInt16(data, (*uint16)(ts))
+}
+
+// Duration converts the timestamp to a duration.
+func (ts RelativeTimestamp) Duration() time.Duration {
+	return time.Duration(ts) * time.Millisecond
+}
+
+// TimeDelay is the time in milliseconds until the frame shall be sent.
+type TimeDelay uint32
+
+// InfoType returns the additional info type.
+func (TimeDelay) InfoType() InfoType {
+	return InfoTimeDelay
+}
+
+// Size returns the packed size.
+func (TimeDelay) Size() uint {
+	return 4
+}
+
+// Pack the entry into the buffer.
+func (delay TimeDelay) Pack(buffer []byte) {
+	util.PackUInt32(buffer, uint32(delay))
+}
+
+// Unpack initializes the value by parsing the given data.
+func (delay *TimeDelay) Unpack(data []byte) (uint, error) {
+	return util.UnpackUInt32(data, (*uint32)(delay))
+}
+
+// Duration converts the delay to a duration.
+func (delay TimeDelay) Duration() time.Duration {
+	return time.Duration(delay) * time.Millisecond
+}
+
+// ExtendedRelativeTimestamp is a timestamp in microseconds, relative to a free-running 32-bit
+// counter.
+type ExtendedRelativeTimestamp uint32
+
+// InfoType returns the additional info type.
+func (ExtendedRelativeTimestamp) InfoType() InfoType {
+	return InfoExtendedRelativeTimestamp
+}
+
+// Size returns the packed size.
+func (ExtendedRelativeTimestamp) Size() uint {
+	return 4
+}
+
+// Pack the entry into the buffer.
+func (ts ExtendedRelativeTimestamp) Pack(buffer []byte) {
+	util.PackUInt32(buffer, uint32(ts))
+}
+
+// Unpack initializes the value by parsing the given data.
+func (ts *ExtendedRelativeTimestamp) Unpack(data []byte) (uint, error) {
+	return util.UnpackUInt32(data, (*uint32)(ts))
+}
+
+// Duration converts the timestamp to a duration.
+func (ts ExtendedRelativeTimestamp) Duration() time.Duration {
+	return time.Duration(ts) * time.Microsecond
+}
+
+// BiBatInfo is the additional info for frames on a BiBat medium.
+type BiBatInfo struct {
+	Control     uint8
+	BlockNumber uint8
+}
+
+// InfoType returns the additional info type.
+func (BiBatInfo) InfoType() InfoType {
+	return InfoBiBat
+}
+
+// Size returns the packed size.
+func (BiBatInfo) Size() uint {
+	return 2
+}
+
+// Pack the entry into the buffer.
+func (bb *BiBatInfo) Pack(buffer []byte) {
+	util.PackSome(buffer, bb.Control, bb.BlockNumber)
+}
+
+// Unpack initializes the structure by parsing the given data.
+func (bb *BiBatInfo) Unpack(data []byte) (uint, error) {
+	return util.UnpackSome(data, &bb.Control, &bb.BlockNumber)
+}
+
+// RFMultiInfo is the additional info for frames on a KNX RF Multi medium.
+type RFMultiInfo struct {
+	TransmissionFrequency uint8
+	CallChannel           uint8
+	FastAck               uint8
+	ReceptionFrequency    uint8
+}
+
+// InfoType returns the additional info type.
+func (RFMultiInfo) InfoType() InfoType {
+	return InfoRFMulti
+}
+
+// Size returns the packed size.
+func (RFMultiInfo) Size() uint {
+	return 4
+}
+
+// Pack the entry into the buffer.
+func (rm *RFMultiInfo) Pack(buffer []byte) {
+	util.PackSome(buffer, rm.TransmissionFrequency, rm.CallChannel, rm.FastAck, rm.ReceptionFrequency)
+}
+
+// Unpack initializes the structure by parsing the given data.
+func (rm *RFMultiInfo) Unpack(data []byte) (uint, error) {
+	return util.UnpackSome(
+		data, &rm.TransmissionFrequency, &rm.CallChannel, &rm.FastAck, &rm.ReceptionFrequency,
+	)
+}
+
+// RFFastAck is a fast acknowledgement received on a KNX RF Multi medium.
+type RFFastAck struct {
+	Status uint8
+	Info   uint8
+}
+
+// RFFastAckInfo contains the fast acknowledgements which have been received for a frame.
+type RFFastAckInfo struct {
+	Acks []RFFastAck
+}
+
+// InfoType returns the additional info type.
+func (RFFastAckInfo) InfoType() InfoType {
+	return InfoRFFastAck
+}
+
+// Size returns the packed size.
+func (fa *RFFastAckInfo) Size() uint {
+	return 2 * uint(len(fa.Acks))
+}
+
+// Pack the entry into the buffer.
+func (fa *RFFastAckInfo) Pack(buffer []byte) {
+	for i, ack := range fa.Acks {
+		buffer[2*i] = ack.Status
+		buffer[2*i+1] = ack.Info
+	}
+}
+
+// Unpack initializes the structure by parsing the given data.
+func (fa *RFFastAckInfo) Unpack(data []byte) (uint, error) {
+	if len(data)%2 != 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	fa.Acks = make([]RFFastAck, len(data)/2)
+	for i := range fa.Acks {
+		fa.Acks[i] = RFFastAck{Status: data[2*i], Info: data[2*i+1]}
+	}
+
+	return uint(len(data)), nil
+}
+
+// PreamblePostambleInfo contains the lengths of the preamble and postamble of a frame.
+type PreamblePostambleInfo struct {
+	PreambleLength  uint16
+	PostambleLength uint8
+}
+
+// InfoType returns the additional info type.
+func (PreamblePostambleInfo) InfoType() InfoType {
+	return InfoPreamblePostamble
+}
+
+// Size returns the packed size.
+func (PreamblePostambleInfo) Size() uint {
+	return 3
+}
+
+// Pack the entry into the buffer.
+func (pp *PreamblePostambleInfo) Pack(buffer []byte) {
+	util.PackSome(buffer, pp.PreambleLength, pp.PostambleLength)
+}
+
+// Unpack initializes the structure by parsing the given data.
+func (pp *PreamblePostambleInfo) Unpack(data []byte) (uint, error) {
+	return util.UnpackSome(data, &pp.PreambleLength, &pp.PostambleLength)
+}
+
+// ManufacturerInfo contains manufacturer-specific data.
+type ManufacturerInfo struct {
+	Manufacturer uint16
+	Subfunction  uint8
+	Data         []byte
+}
+
+// InfoType returns the additional info type.
+func (ManufacturerInfo) InfoType() InfoType {
+	return InfoManufacturer
+}
+
+// Size returns the packed size.
+func (mi *ManufacturerInfo) Size() uint {
+	return 3 + uint(len(mi.Data))
+}
+
+// Pack the entry into the buffer.
+func (mi *ManufacturerInfo) Pack(buffer []byte) {
+	util.PackSome(buffer, mi.Manufacturer, mi.Subfunction, mi.Data)
+}
+
+// Unpack initializes the structure by parsing the given data.
+func (mi *ManufacturerInfo) Unpack(data []byte) (n uint, err error) {
+	if n, err = util.UnpackSome(data, &mi.Manufacturer, &mi.Subfunction); err != nil {
+		return
+	}
+
+	mi.Data = make([]byte, len(data)-int(n))
+	n += uint(copy(mi.Data, data[n:]))
+
+	return
+}
+
+// UnknownInfo is an additional info entry of unknown type.
+type UnknownInfo struct {
+	Type InfoType
+	Data []byte
+}
+
+// InfoType returns the additional info type.
+func (ui *UnknownInfo) InfoType() InfoType {
+	return ui.Type
+}
+
+// Size returns the packed size.
+func (ui *UnknownInfo) Size() uint {
+	return uint(len(ui.Data))
+}
+
+// Pack the entry into the buffer.
+func (ui *UnknownInfo) Pack(buffer []byte) {
+	copy(buffer, ui.Data)
+}
+
+// Unpack initializes the structure by parsing the given data.
+func (ui *UnknownInfo) Unpack(data []byte) (uint, error) {
+	ui.Data = make([]byte, len(data))
+	return uint(copy(ui.Data, data)), nil
+}
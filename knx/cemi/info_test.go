@@ -0,0 +1,98 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package cemi
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInfo_Entries(t *testing.T) {
+	data := []byte{
+		0x03, 0x01, 0xA9,
+		0x04, 0x02, 0x12, 0x34,
+		0x06, 0x04, 0x00, 0x01, 0x00, 0x00,
+		0x02, 0x08, 0x26, 0x00, 0x83, 0x12, 0x34, 0x56, 0x78, 0x05,
+		0x08, 0x04, 0x01, 0x02, 0x03, 0x04,
+		0x0A, 0x04, 0x10, 0x20, 0x11, 0x21,
+		0xFE, 0x04, 0x00, 0x83, 0x01, 0xFF,
+		0x42, 0x01, 0x00,
+	}
+
+	entries, err := Info(data).Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := RelativeTimestamp(0x1234)
+	ets := ExtendedRelativeTimestamp(0x10000)
+
+	expected := []InfoEntry{
+		&BusmonitorInfo{FrameError: true, ParityError: true, Lost: true, SequenceNumber: 1},
+		&ts,
+		&ets,
+		&RFMediumInfo{
+			RSS:              SignalStrength(2),
+			RetransmitterRSS: SignalStrength(1),
+			BatteryOK:        true,
+			SerialNumber:     SerialNumber{0x00, 0x83, 0x12, 0x34, 0x56, 0x78},
+			LFN:              5,
+		},
+		&RFMultiInfo{TransmissionFrequency: 1, CallChannel: 2, FastAck: 3, ReceptionFrequency: 4},
+		&RFFastAckInfo{Acks: []RFFastAck{{Status: 0x10, Info: 0x20}, {Status: 0x11, Info: 0x21}}},
+		&ManufacturerInfo{Manufacturer: 0x83, Subfunction: 1, Data: []byte{0xFF}},
+		&UnknownInfo{Type: 0x42, Data: []byte{0}},
+	}
+
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+
+	if ets.Duration() != 65536*time.Microsecond {
+		t.Errorf("Unexpected duration: %v", ets.Duration())
+	}
+
+	if repacked := NewInfo(entries...); !bytes.Equal(repacked, data) {
+		t.Errorf("Repacked info mismatches: %v != %v", repacked, data)
+	}
+}
+
+func TestInfo_EntriesInvalid(t *testing.T) {
+	invalid := [][]byte{
+		{0x04},
+		{0x04, 0x03, 0x00, 0x00},
+		{0x04, 0x01, 0x00},
+		{0x02, 0x02, 0x00, 0x00},
+		{0x08, 0x02, 0x00, 0x00},
+		{0x0A, 0x03, 0x00, 0x00, 0x00},
+	}
+
+	for _, data := range invalid {
+		if _, err := Info(data).Entries(); err == nil {
+			t.Errorf("Should not succeed: %v", data)
+		}
+	}
+}
+
+func TestNewInfo(t *testing.T) {
+	info := NewInfo(
+		&PLMediumInfo{DomainAddress: 0x1234},
+		TimeDelay(100),
+		&BiBatInfo{Control: 1, BlockNumber: 2},
+		&PreamblePostambleInfo{PreambleLength: 0x0102, PostambleLength: 3},
+	)
+
+	expected := []byte{
+		0x01, 0x02, 0x12, 0x34,
+		0x05, 0x04, 0x00, 0x00, 0x00, 0x64,
+		0x07, 0x02, 0x01, 0x02,
+		0x09, 0x03, 0x01, 0x02, 0x03,
+	}
+
+	if !bytes.Equal(info, expected) {
+		t.Errorf("Unexpected info: %v", info)
+	}
+}
@@ -3,7 +3,18 @@
 
 package cemi
 
-import "github.com/vapourismo/knx-go/knx/util"
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	// MaxStandardAPDULength is the maximum APDU length that fits into a standard frame.
+	MaxStandardAPDULength = 15
+
+	// MaxExtendedAPDULength is the maximum APDU length that fits into an extended frame.
+	MaxExtendedAPDULength = 254
+)
 
 // A LData is a link-layer data frame. L_Data.req, L_Data.con and L_Data.ind share this structure.
 type LData struct {
@@ -17,18 +28,28 @@ type LData struct {
 
 // Unpack initializes the structure by parsing the given data.
 func (ldata *LData) Unpack(data []byte) (n uint, err error) {
-	if n, err = util.UnpackSome(
-		data,
-		&ldata.Info,
-		(*uint8)(&ldata.Control1),
-		(*uint8)(&ldata.Control2),
-		(*uint16)(&ldata.Source),
-		&ldata.Destination,
-	); err != nil {
+	return ldata.unpack(data, false)
+}
+
+// unpack parses the frame. If reuse is true, the existing info and transport unit are overwritten
+// instead of allocating new ones.
+func (ldata *LData) unpack(data []byte, reuse bool) (n uint, err error) {
+	if n, err = ldata.Info.unpack(data, reuse); err != nil {
 		return
 	}
 
-	m, err := unpackTransportUnit(data[n:], &ldata.Data)
+	if len(data) < int(n)+6 {
+		return n, io.ErrUnexpectedEOF
+	}
+
+	header := data[n:]
+	ldata.Control1 = ControlField1(header[0])
+	ldata.Control2 = ControlField2(header[1])
+	ldata.Source = IndividualAddr(header[2])<<8 | IndividualAddr(header[3])
+	ldata.Destination = uint16(header[4])<<8 | uint16(header[5])
+	n += 6
+
+	m, err := unpackTransportUnitInto(data[n:], &ldata.Data, reuse)
 	n += m
 
 	return
@@ -41,15 +62,70 @@ func (ldata *LData) Size() uint {
 
 // Pack the message body into the buffer.
 func (ldata *LData) Pack(buffer []byte) {
-	util.PackSome(
-		buffer,
-		ldata.Info,
-		uint8(ldata.Control1),
-		uint8(ldata.Control2),
-		uint16(ldata.Source),
-		ldata.Destination,
-		ldata.Data,
-	)
+	ldata.Info.Pack(buffer)
+
+	header := buffer[ldata.Info.Size():]
+	header[0] = byte(ldata.Control1)
+	header[1] = byte(ldata.Control2)
+	header[2] = byte(ldata.Source >> 8)
+	header[3] = byte(ldata.Source)
+	header[4] = byte(ldata.Destination >> 8)
+	header[5] = byte(ldata.Destination)
+
+	ldata.Data.Pack(header[6:])
+}
+
+// APDULength returns the length of the application data unit as it is given in the frame's length
+// field.
+func (ldata *LData) APDULength() uint {
+	switch unit := ldata.Data.(type) {
+	case *AppData:
+		if len(unit.Data) < 1 {
+			return 1
+		}
+
+		return uint(len(unit.Data))
+
+	default:
+		return 0
+	}
+}
+
+// NeedsExtendedFrame determines whether the frame can only be transmitted as an extended frame.
+// This is the case for long APDUs and frames with an extended frame format such as LTE-HEE.
+func (ldata *LData) NeedsExtendedFrame() bool {
+	return ldata.APDULength() > MaxStandardAPDULength || ldata.Control2.ExtendedFormat() != 0
+}
+
+// SetFrameType sets or clears Control1StdFrame depending on whether the frame needs to be an
+// extended frame.
+func (ldata *LData) SetFrameType() {
+	if ldata.NeedsExtendedFrame() {
+		ldata.Control1 &^= Control1StdFrame
+	} else {
+		ldata.Control1 |= Control1StdFrame
+	}
+}
+
+// Validate makes sure that the frame is consistent and that its APDU does not exceed the given
+// maximum length. A maximum length of 0 permits APDUs up to MaxExtendedAPDULength.
+func (ldata *LData) Validate(maxAPDULength uint) error {
+	if maxAPDULength == 0 || maxAPDULength > MaxExtendedAPDULength {
+		maxAPDULength = MaxExtendedAPDULength
+	}
+
+	length := ldata.APDULength()
+
+	if length > maxAPDULength {
+		return fmt.Errorf("APDU length %d exceeds maximum of %d", length, maxAPDULength)
+	}
+
+	if ldata.Control1&Control1StdFrame != 0 && ldata.NeedsExtendedFrame() {
+		return fmt.Errorf("frame with APDU length %d and extended format %d is marked as standard frame",
+			length, ldata.Control2.ExtendedFormat())
+	}
+
+	return nil
 }
 
 // A LDataReq represents a L_Data.req message body.
@@ -69,3 +69,63 @@ func TestLData_Unpack(t *testing.T) {
 		}
 	}
 }
+
+func TestLData_Validate(t *testing.T) {
+	ldata := LData{
+		Control2: Control2GroupAddr,
+		Data:     &AppData{Command: GroupValueWrite, Data: make([]byte, 16)},
+	}
+
+	ldata.SetFrameType()
+
+	if ldata.Control1&Control1StdFrame != 0 {
+		t.Error("Long APDU should require an extended frame")
+	}
+
+	if err := ldata.Validate(0); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+
+	if err := ldata.Validate(15); err == nil {
+		t.Error("APDU should exceed the maximum length")
+	}
+
+	ldata.Control1 |= Control1StdFrame
+	if err := ldata.Validate(0); err == nil {
+		t.Error("Long APDU in a standard frame should be invalid")
+	}
+
+	ldata.Data = &AppData{Command: GroupValueWrite, Data: make([]byte, 255)}
+	if err := ldata.Validate(0); err == nil {
+		t.Error("APDU should exceed the maximum extended frame length")
+	}
+
+	ldata.Data = &AppData{Command: GroupValueWrite, Data: []byte{1}}
+	ldata.Control2 = Control2LTE(LTEGeographical)
+	ldata.SetFrameType()
+
+	if !ldata.Control2.IsLTE() || ldata.Control1&Control1StdFrame != 0 {
+		t.Error("LTE frame should require an extended frame")
+	}
+}
+
+func TestLTEZone(t *testing.T) {
+	zone, err := NewLTEZoneString("12/34/5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if zone != NewLTEZone(12, 34, 5) || zone.AptFloor() != 12 || zone.Room() != 34 || zone.Subzone() != 5 {
+		t.Errorf("Unexpected zone: %v", zone)
+	}
+
+	if zone.String() != "12/34/5" {
+		t.Errorf("Unexpected string representation: %s", zone)
+	}
+
+	for _, invalid := range []string{"64/0/0", "0/0/16", "1/2", "a/b/c"} {
+		if _, err := NewLTEZoneString(invalid); err == nil {
+			t.Errorf("Should not succeed: %s", invalid)
+		}
+	}
+}
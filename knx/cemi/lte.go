@@ -0,0 +1,104 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package cemi
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LTEAddrType determines how the destination of a LTE-HEE frame is interpreted.
+type LTEAddrType uint8
+
+// These are the LTE-HEE extended address types.
+const (
+	// LTEGeographical addresses a zone given by apartment/floor, room and subzone.
+	LTEGeographical LTEAddrType = 0
+
+	// LTEAppSpecific addresses an application specific tag.
+	LTEAppSpecific LTEAddrType = 1
+
+	// LTEPeripheral addresses unassigned peripheral devices.
+	LTEPeripheral LTEAddrType = 2
+
+	// LTEBroadcast addresses all LTE-HEE devices.
+	LTEBroadcast LTEAddrType = 3
+)
+
+// String generates a string representation.
+func (addrType LTEAddrType) String() string {
+	switch addrType {
+	case LTEGeographical:
+		return "Geographical"
+
+	case LTEAppSpecific:
+		return "AppSpecific"
+
+	case LTEPeripheral:
+		return "Peripheral"
+
+	case LTEBroadcast:
+		return "Broadcast"
+
+	default:
+		return fmt.Sprintf("%#x", uint8(addrType))
+	}
+}
+
+// LTEZone is a geographical LTE-HEE destination. It consists of the apartment/floor [0..63], the
+// room [0..63] and the subzone [0..15]. Zero in any of these parts addresses all of them.
+type LTEZone uint16
+
+// NewLTEZone generates a zone from its apartment/floor, room and subzone.
+func NewLTEZone(aptFloor, room, subzone uint8) LTEZone {
+	return LTEZone(aptFloor&0x3F)<<10 | LTEZone(room&0x3F)<<4 | LTEZone(subzone&0xF)
+}
+
+// NewLTEZoneString parses a zone in the format "a/r/s".
+func NewLTEZoneString(zone string) (LTEZone, error) {
+	parts := strings.Split(zone, "/")
+	if len(parts) != 3 {
+		return 0, errors.New("string cannot be parsed to a LTE zone")
+	}
+
+	limits := [3]int{63, 63, 15}
+	nums := [3]uint8{}
+
+	for i, s := range parts {
+		num, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, err
+		}
+
+		if num < 0 || num > limits[i] {
+			return 0, fmt.Errorf("invalid apartment/floor, room or subzone in %s", zone)
+		}
+
+		nums[i] = uint8(num)
+	}
+
+	return NewLTEZone(nums[0], nums[1], nums[2]), nil
+}
+
+// AptFloor returns the apartment/floor.
+func (zone LTEZone) AptFloor() uint8 {
+	return uint8(zone>>10) & 0x3F
+}
+
+// Room returns the room.
+func (zone LTEZone) Room() uint8 {
+	return uint8(zone>>4) & 0x3F
+}
+
+// Subzone returns the subzone.
+func (zone LTEZone) Subzone() uint8 {
+	return uint8(zone) & 0xF
+}
+
+// String generates a string representation "a/r/s".
+func (zone LTEZone) String() string {
+	return fmt.Sprintf("%d/%d/%d", zone.AptFloor(), zone.Room(), zone.Subzone())
+}
@@ -0,0 +1,326 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package cemi
+
+import (
+	"fmt"
+
+	"github.com/vapourismo/knx-go/knx/util"
+)
+
+// InterfaceObjectType identifies the type of an interface object.
+type InterfaceObjectType uint16
+
+// These are known interface object types.
+const (
+	ObjectDevice             InterfaceObjectType = 0
+	ObjectAddressTable       InterfaceObjectType = 1
+	ObjectAssociationTable   InterfaceObjectType = 2
+	ObjectApplicationProgram InterfaceObjectType = 3
+	ObjectInterfaceProgram   InterfaceObjectType = 4
+	ObjectRouter             InterfaceObjectType = 6
+	ObjectLTEAddressRouting  InterfaceObjectType = 7
+	ObjectCEMIServer         InterfaceObjectType = 8
+	ObjectGroupObjectTable   InterfaceObjectType = 9
+	ObjectPollingMaster      InterfaceObjectType = 10
+	ObjectKNXnetIPParameter  InterfaceObjectType = 11
+	ObjectFileServer         InterfaceObjectType = 13
+	ObjectSecurity           InterfaceObjectType = 17
+	ObjectRFMedium           InterfaceObjectType = 19
+)
+
+// PropertyError is an error code in a negative M_PropRead.con or M_PropWrite.con.
+type PropertyError uint8
+
+// These are known property error codes.
+const (
+	PropErrUnspecified       PropertyError = 0x00
+	PropErrOutOfRange        PropertyError = 0x01
+	PropErrOutOfMaxRange     PropertyError = 0x02
+	PropErrOutOfMinRange     PropertyError = 0x03
+	PropErrMemory            PropertyError = 0x04
+	PropErrReadOnly          PropertyError = 0x05
+	PropErrIllegalCommand    PropertyError = 0x06
+	PropErrVoidDatapoint     PropertyError = 0x07
+	PropErrTypeConflict      PropertyError = 0x08
+	PropErrIndexRange        PropertyError = 0x09
+	PropErrTemporaryReadOnly PropertyError = 0x0A
+)
+
+// Error returns a description of the error code.
+func (code PropertyError) Error() string {
+	switch code {
+	case PropErrUnspecified:
+		return "unspecified property error"
+
+	case PropErrOutOfRange:
+		return "property value out of range"
+
+	case PropErrOutOfMaxRange:
+		return "property value exceeds maximum"
+
+	case PropErrOutOfMinRange:
+		return "property value below minimum"
+
+	case PropErrMemory:
+		return "property memory error"
+
+	case PropErrReadOnly:
+		return "property is read-only"
+
+	case PropErrIllegalCommand:
+		return "illegal property command"
+
+	case PropErrVoidDatapoint:
+		return "property does not exist"
+
+	case PropErrTypeConflict:
+		return "property type conflict"
+
+	case PropErrIndexRange:
+		return "property index out of range"
+
+	case PropErrTemporaryReadOnly:
+		return "property is temporarily not writable"
+
+	default:
+		return fmt.Sprintf("property error %#02x", uint8(code))
+	}
+}
+
+// A MProp is a local device management property frame. M_PropRead.req, M_PropRead.con,
+// M_PropWrite.req, M_PropWrite.con and M_PropInfo.ind share this structure.
+type MProp struct {
+	ObjectType     InterfaceObjectType
+	ObjectInstance uint8
+	PropertyID     uint8
+
+	// Number of elements, at most 15
+	Count uint8
+
+	// Index of the first element, at most 4095
+	StartIndex uint16
+
+	// Property data; contains the error code in a negative confirmation
+	Data []byte
+}
+
+// Size returns the packed size.
+func (prop *MProp) Size() uint {
+	return 6 + uint(len(prop.Data))
+}
+
+// Pack the message body into the buffer.
+func (prop *MProp) Pack(buffer []byte) {
+	util.PackSome(
+		buffer,
+		uint16(prop.ObjectType),
+		prop.ObjectInstance,
+		prop.PropertyID,
+		uint16(prop.Count&15)<<12|prop.StartIndex&0xFFF,
+		prop.Data,
+	)
+}
+
+// Unpack initializes the structure by parsing the given data.
+func (prop *MProp) Unpack(data []byte) (n uint, err error) {
+	var countIndex uint16
+
+	if n, err = util.UnpackSome(
+		data,
+		(*uint16)(&prop.ObjectType),
+		&prop.ObjectInstance,
+		&prop.PropertyID,
+		&countIndex,
+	); err != nil {
+		return
+	}
+
+	prop.Count = uint8(countIndex >> 12)
+	prop.StartIndex = countIndex & 0xFFF
+
+	prop.Data = make([]byte, len(data)-int(n))
+	n += uint(copy(prop.Data, data[n:]))
+
+	return
+}
+
+// ErrorCode returns the error code of a negative confirmation. The second return value is false if
+// the frame does not indicate an error.
+func (prop *MProp) ErrorCode() (PropertyError, bool) {
+	if prop.Count != 0 || len(prop.Data) < 1 {
+		return 0, false
+	}
+
+	return PropertyError(prop.Data[0]), true
+}
+
+// A MPropReadReq represents a M_PropRead.req message body.
+type MPropReadReq struct {
+	MProp
+}
+
+// MessageCode returns the message code for M_PropRead.req.
+func (MPropReadReq) MessageCode() MessageCode {
+	return MPropReadReqCode
+}
+
+// A MPropReadCon represents a M_PropRead.con message body.
+type MPropReadCon struct {
+	MProp
+}
+
+// MessageCode returns the message code for M_PropRead.con.
+func (MPropReadCon) MessageCode() MessageCode {
+	return MPropReadConCode
+}
+
+// A MPropWriteReq represents a M_PropWrite.req message body.
+type MPropWriteReq struct {
+	MProp
+}
+
+// MessageCode returns the message code for M_PropWrite.req.
+func (MPropWriteReq) MessageCode() MessageCode {
+	return MPropWriteReqCode
+}
+
+// A MPropWriteCon represents a M_PropWrite.con message body.
+type MPropWriteCon struct {
+	MProp
+}
+
+// MessageCode returns the message code for M_PropWrite.con.
+func (MPropWriteCon) MessageCode() MessageCode {
+	return MPropWriteConCode
+}
+
+// A MPropInfoInd represents a M_PropInfo.ind message body.
+type MPropInfoInd struct {
+	MProp
+}
+
+// MessageCode returns the message code for M_PropInfo.ind.
+func (MPropInfoInd) MessageCode() MessageCode {
+	return MPropInfoIndCode
+}
+
+// A MFuncProp is a function property frame. M_FuncPropCommand.req, M_FuncPropStateRead.req and
+// their confirmations share this structure.
+type MFuncProp struct {
+	ObjectType     InterfaceObjectType
+	ObjectInstance uint8
+	PropertyID     uint8
+	Data           []byte
+}
+
+// Size returns the packed size.
+func (prop *MFuncProp) Size() uint {
+	return 4 + uint(len(prop.Data))
+}
+
+// Pack the message body into the buffer.
+func (prop *MFuncProp) Pack(buffer []byte) {
+	util.PackSome(
+		buffer,
+		uint16(prop.ObjectType),
+		prop.ObjectInstance,
+		prop.PropertyID,
+		prop.Data,
+	)
+}
+
+// Unpack initializes the structure by parsing the given data.
+func (prop *MFuncProp) Unpack(data []byte) (n uint, err error) {
+	if n, err = util.UnpackSome(
+		data,
+		(*uint16)(&prop.ObjectType),
+		&prop.ObjectInstance,
+		&prop.PropertyID,
+	); err != nil {
+		return
+	}
+
+	prop.Data = make([]byte, len(data)-int(n))
+	n += uint(copy(prop.Data, data[n:]))
+
+	return
+}
+
+// A MFuncPropCommandReq represents a M_FuncPropCommand.req message body.
+type MFuncPropCommandReq struct {
+	MFuncProp
+}
+
+// MessageCode returns the message code for M_FuncPropCommand.req.
+func (MFuncPropCommandReq) MessageCode() MessageCode {
+	return MFuncPropCommandReqCode
+}
+
+// A MFuncPropStateReadReq represents a M_FuncPropStateRead.req message body.
+type MFuncPropStateReadReq struct {
+	MFuncProp
+}
+
+// MessageCode returns the message code for M_FuncPropStateRead.req.
+func (MFuncPropStateReadReq) MessageCode() MessageCode {
+	return MFuncPropStateReadReqCode
+}
+
+// A MFuncPropCon represents a M_FuncPropCommand.con or M_FuncPropStateRead.con message body. The
+// first data byte is the return code.
+type MFuncPropCon struct {
+	MFuncProp
+}
+
+// MessageCode returns the message code for M_FuncPropCommand.con and M_FuncPropStateRead.con.
+func (MFuncPropCon) MessageCode() MessageCode {
+	return MFuncPropConCode
+}
+
+// ReturnCode returns the return code of the function. The second return value is false if the
+// function property does not exist or could not be executed.
+func (con *MFuncPropCon) ReturnCode() (uint8, bool) {
+	if len(con.Data) < 1 {
+		return 0, false
+	}
+
+	return con.Data[0], true
+}
+
+// A MReset is a reset frame without any contents.
+type MReset struct{}
+
+// Size returns the packed size.
+func (MReset) Size() uint {
+	return 0
+}
+
+// Pack the message body into the buffer.
+func (MReset) Pack(buffer []byte) {}
+
+// Unpack initializes the structure by parsing the given data.
+func (*MReset) Unpack(data []byte) (uint, error) {
+	return 0, nil
+}
+
+// A MResetReq represents a M_Reset.req message body.
+type MResetReq struct {
+	MReset
+}
+
+// MessageCode returns the message code for M_Reset.req.
+func (MResetReq) MessageCode() MessageCode {
+	return MResetReqCode
+}
+
+// A MResetInd represents a M_Reset.ind message body.
+type MResetInd struct {
+	MReset
+}
+
+// MessageCode returns the message code for M_Reset.ind.
+func (MResetInd) MessageCode() MessageCode {
+	return MResetIndCode
+}
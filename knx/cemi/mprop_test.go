@@ -0,0 +1,121 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package cemi
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestMProp_Unpack(t *testing.T) {
+	data := []byte{0xFC, 0x00, 0x0B, 0x01, 0x34, 0x10, 0x01}
+
+	var msg Message
+	num, err := Unpack(data, &msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if num != uint(len(data)) {
+		t.Error("Unexpected length:", num, len(data))
+	}
+
+	req, ok := msg.(*MPropReadReq)
+	if !ok {
+		t.Fatalf("Unexpected message type: %T", msg)
+	}
+
+	expected := MProp{
+		ObjectType:     ObjectKNXnetIPParameter,
+		ObjectInstance: 1,
+		PropertyID:     52,
+		Count:          1,
+		StartIndex:     1,
+		Data:           []byte{},
+	}
+
+	if !reflect.DeepEqual(req.MProp, expected) {
+		t.Errorf("Unexpected result: %+v", req.MProp)
+	}
+}
+
+func TestMProp_ErrorCode(t *testing.T) {
+	con := MPropWriteCon{MProp{ObjectType: ObjectDevice, PropertyID: 54, StartIndex: 1, Data: []byte{0x05}}}
+
+	code, ok := con.ErrorCode()
+	if !ok || code != PropErrReadOnly {
+		t.Errorf("Unexpected error code: %v %v", code, ok)
+	}
+
+	con.Count = 1
+	if _, ok := con.ErrorCode(); ok {
+		t.Error("Positive confirmation must not have an error code")
+	}
+}
+
+func TestDeviceManagement_RoundTrip(t *testing.T) {
+	makeProp := func() MProp {
+		return MProp{
+			ObjectType:     InterfaceObjectType(rand.Int()),
+			ObjectInstance: uint8(rand.Int()),
+			PropertyID:     uint8(rand.Int()),
+			Count:          uint8(rand.Int() % 16),
+			StartIndex:     uint16(rand.Int() % 4096),
+			Data:           makeRandBuffer(rand.Int() % 20),
+		}
+	}
+
+	makeFuncProp := func() MFuncProp {
+		return MFuncProp{
+			ObjectType:     InterfaceObjectType(rand.Int()),
+			ObjectInstance: uint8(rand.Int()),
+			PropertyID:     uint8(rand.Int()),
+			Data:           makeRandBuffer(rand.Int() % 20),
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		messages := []Message{
+			&MPropReadReq{makeProp()},
+			&MPropReadCon{makeProp()},
+			&MPropWriteReq{makeProp()},
+			&MPropWriteCon{makeProp()},
+			&MPropInfoInd{makeProp()},
+			&MFuncPropCommandReq{makeFuncProp()},
+			&MFuncPropStateReadReq{makeFuncProp()},
+			&MFuncPropCon{makeFuncProp()},
+			&MResetReq{},
+			&MResetInd{},
+		}
+
+		for _, msg := range messages {
+			buffer := make([]byte, Size(msg))
+			Pack(buffer, msg)
+
+			var result Message
+			num, err := Unpack(buffer, &result)
+			if err != nil {
+				t.Error("Unexpected error:", err, buffer)
+				continue
+			}
+
+			if num != uint(len(buffer)) {
+				t.Error("Unexpected length:", num, len(buffer))
+			}
+
+			if !reflect.DeepEqual(msg, result) {
+				t.Errorf("Unexpected result: %+v != %+v", result, msg)
+			}
+
+			repacked := make([]byte, Size(result))
+			Pack(repacked, result)
+
+			if !bytes.Equal(buffer, repacked) {
+				t.Error("Repacked frame mismatches:", repacked, buffer)
+			}
+		}
+	}
+}
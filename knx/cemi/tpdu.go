@@ -111,13 +111,24 @@ type TransportUnit interface {
 
 // unpackTransportUnit parses the given data in order to extract the transport unit that it encodes.
 func unpackTransportUnit(data []byte, unit *TransportUnit) (uint, error) {
+	return unpackTransportUnitInto(data, unit, false)
+}
+
+// unpackTransportUnitInto works like unpackTransportUnit. If reuse is true, a transport unit of the
+// same kind in unit is overwritten instead of allocating a new one.
+func unpackTransportUnitInto(data []byte, unit *TransportUnit, reuse bool) (uint, error) {
 	if len(data) < 2 {
 		return 0, io.ErrUnexpectedEOF
 	}
 
 	// Does unit contain control information?
 	if (data[1] & (1 << 7)) == 1<<7 {
-		control := &ControlData{
+		control, ok := (*unit).(*ControlData)
+		if !reuse || !ok || control == nil {
+			control = &ControlData{}
+		}
+
+		*control = ControlData{
 			Numbered:  (data[1] & (1 << 6)) == 1<<6,
 			SeqNumber: (data[1] >> 2) & 15,
 			Command:   data[1] & 3,
@@ -134,17 +145,91 @@ func unpackTransportUnit(data []byte, unit *TransportUnit) (uint, error) {
 		return 0, io.ErrUnexpectedEOF
 	}
 
-	app := &AppData{
+	app, ok := (*unit).(*AppData)
+	if !reuse || !ok || app == nil {
+		app = &AppData{}
+	}
+
+	buffer := app.Data
+	if !reuse || cap(buffer) < dataLength {
+		buffer = make([]byte, dataLength)
+	} else {
+		buffer = buffer[:dataLength]
+	}
+
+	*app = AppData{
 		Numbered:  (data[1] & (1 << 6)) == 1<<6,
 		SeqNumber: (data[1] >> 2) & 15,
 		Command:   APCI((data[1]&3)<<2 | data[2]>>6),
-		Data:      make([]byte, dataLength),
+		Data:      buffer,
+	}
+
+	// Reused buffers must not retain previous contents if the data is short.
+	for i := copy(app.Data, data[2:]); i < dataLength; i++ {
+		app.Data[i] = 0
 	}
 
-	copy(app.Data, data[2:])
 	app.Data[0] &= 63
 
 	*unit = app
 
 	return uint(dataLength) + 2, nil
 }
+
+// ExtendedAPCI is the full 10-bit Application-layer Protocol Control Information. The upper 4 bits
+// are equivalent to an APCI, the lower 6 bits are carried in the first byte of the application data.
+type ExtendedAPCI uint16
+
+// These are usable extended APCI values.
+const (
+	MemoryExtendedWrite                ExtendedAPCI = 0x1FB
+	MemoryExtendedWriteResponse        ExtendedAPCI = 0x1FC
+	MemoryExtendedRead                 ExtendedAPCI = 0x1FD
+	MemoryExtendedReadResponse         ExtendedAPCI = 0x1FE
+	RestartMasterReset                 ExtendedAPCI = 0x381
+	RestartResponse                    ExtendedAPCI = 0x3A1
+	PropertyValueRead                  ExtendedAPCI = 0x3D5
+	PropertyValueResponse              ExtendedAPCI = 0x3D6
+	PropertyValueWrite                 ExtendedAPCI = 0x3D7
+	PropertyDescriptionRead            ExtendedAPCI = 0x3D8
+	PropertyDescriptionResponse        ExtendedAPCI = 0x3D9
+	NetworkParameterRead               ExtendedAPCI = 0x3DA
+	NetworkParameterResponse           ExtendedAPCI = 0x3DB
+	IndividualAddrSerialNumberRead     ExtendedAPCI = 0x3DC
+	IndividualAddrSerialNumberResponse ExtendedAPCI = 0x3DD
+	IndividualAddrSerialNumberWrite    ExtendedAPCI = 0x3DE
+	DomainAddressWrite                 ExtendedAPCI = 0x3E0
+	DomainAddressRead                  ExtendedAPCI = 0x3E1
+	DomainAddressResponse              ExtendedAPCI = 0x3E2
+	DomainAddressSelectiveRead         ExtendedAPCI = 0x3E3
+	NetworkParameterWrite              ExtendedAPCI = 0x3E4
+)
+
+// APCI returns the 4-bit APCI portion.
+func (apci ExtendedAPCI) APCI() APCI {
+	return APCI(apci>>6) & 15
+}
+
+// NewExtendedAppData creates application data for the given extended APCI. The data does not
+// include the byte which holds the lower 6 bits of the extended APCI.
+func NewExtendedAppData(apci ExtendedAPCI, data []byte) *AppData {
+	buffer := make([]byte, 1+len(data))
+	buffer[0] = byte(apci & 63)
+	copy(buffer[1:], data)
+
+	return &AppData{
+		Command: apci.APCI(),
+		Data:    buffer,
+	}
+}
+
+// ExtendedCommand retrieves the extended APCI.
+func (app *AppData) ExtendedCommand() ExtendedAPCI {
+	apci := ExtendedAPCI(app.Command&15) << 6
+
+	if len(app.Data) > 0 {
+		apci |= ExtendedAPCI(app.Data[0] & 63)
+	}
+
+	return apci
+}
@@ -193,3 +193,34 @@ func TestUnpackTransportUnit(t *testing.T) {
 		}
 	})
 }
+
+func TestAppData_ExtendedCommand(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		apci := ExtendedAPCI(rand.Int() % 1024)
+		payload := makeRandBuffer(rand.Int() % 20)
+
+		app := NewExtendedAppData(apci, payload)
+
+		if app.Command != APCI(apci>>6) {
+			t.Error("Unexpected command:", app.Command, apci)
+		}
+
+		if app.ExtendedCommand() != apci {
+			t.Error("Unexpected extended command:", app.ExtendedCommand(), apci)
+		}
+
+		if !bytes.Equal(app.Data[1:], payload) {
+			t.Error("Unexpected data:", app.Data[1:], payload)
+		}
+
+		var unit TransportUnit
+		if _, err := unpackTransportUnit(util.AllocAndPack(app), &unit); err != nil {
+			t.Error("Unexpected error:", err)
+			continue
+		}
+
+		if unit.(*AppData).ExtendedCommand() != apci {
+			t.Error("Unexpected extended command after unpack:", unit.(*AppData).ExtendedCommand(), apci)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"fmt"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
+)
+
+// DecodedGroupEvent is a group event whose data has been decoded according to the datapoint type
+// of its destination.
+type DecodedGroupEvent struct {
+	GroupEvent
+
+	// Datapoint type name (e.g. "9.001") of the destination, empty if the address is unmapped
+	DPT string
+
+	// Decoded value, nil if the address is unmapped, the event is a GroupRead or decoding failed
+	Value dpt.Datapoint
+
+	// Error that occurred while decoding the data
+	Err error
+}
+
+// A GroupDecoder decodes the events of a group client using the datapoint types that are
+// assigned to the group addresses. Events for unmapped addresses are delivered with their raw
+// data only.
+type GroupDecoder struct {
+	client  GroupClient
+	types   map[cemi.GroupAddr]string
+	inbound chan DecodedGroupEvent
+}
+
+// NewGroupDecoder creates a decoder which consumes the inbound events of the given client. The
+// types map assigns datapoint type names (e.g. "1.001") to group addresses.
+func NewGroupDecoder(client GroupClient, types map[cemi.GroupAddr]string) *GroupDecoder {
+	decoder := &GroupDecoder{
+		client:  client,
+		types:   make(map[cemi.GroupAddr]string, len(types)),
+		inbound: make(chan DecodedGroupEvent),
+	}
+
+	for addr, name := range types {
+		decoder.types[addr] = name
+	}
+
+	go decoder.serve()
+
+	return decoder
+}
+
+// serve decodes the inbound events of the client.
+func (decoder *GroupDecoder) serve() {
+	for event := range decoder.client.Inbound() {
+		decoder.inbound <- decoder.Decode(event)
+	}
+
+	close(decoder.inbound)
+}
+
+// Decode decodes the data of a single event.
+func (decoder *GroupDecoder) Decode(event GroupEvent) DecodedGroupEvent {
+	decoded := DecodedGroupEvent{GroupEvent: event}
+
+	name, ok := decoder.types[event.Destination]
+	if !ok {
+		return decoded
+	}
+
+	decoded.DPT = name
+
+	// Read requests carry no value.
+	if event.Command == GroupRead {
+		return decoded
+	}
+
+	value, ok := dpt.Produce(name)
+	if !ok {
+		decoded.Err = fmt.Errorf("unknown datapoint type %q for %v", name, event.Destination)
+		return decoded
+	}
+
+	if err := value.Unpack(event.Data); err != nil {
+		decoded.Err = fmt.Errorf("failed to decode %v as %s: %v", event.Destination, name, err)
+		return decoded
+	}
+
+	decoded.Value = value
+
+	return decoded
+}
+
+// Send a group event using the underlying client.
+func (decoder *GroupDecoder) Send(event GroupEvent) error {
+	return decoder.client.Send(event)
+}
+
+// Write sends a GroupValueWrite with the packed value to the given address.
+func (decoder *GroupDecoder) Write(dest cemi.GroupAddr, value dpt.DatapointValue) error {
+	return decoder.client.Send(GroupEvent{
+		Command:     GroupWrite,
+		Destination: dest,
+		Data:        value.Pack(),
+	})
+}
+
+// Inbound returns the channel on which decoded events are delivered. The channel is closed once
+// the inbound channel of the underlying client has been closed.
+func (decoder *GroupDecoder) Inbound() <-chan DecodedGroupEvent {
+	return decoder.inbound
+}
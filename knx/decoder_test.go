@@ -0,0 +1,95 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"testing"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
+)
+
+// dummyGroupClient is a GroupClient which is controlled through its channels.
+type dummyGroupClient struct {
+	inbound  chan GroupEvent
+	outbound chan GroupEvent
+}
+
+func newDummyGroupClient() *dummyGroupClient {
+	return &dummyGroupClient{
+		inbound:  make(chan GroupEvent),
+		outbound: make(chan GroupEvent, 10),
+	}
+}
+
+func (client *dummyGroupClient) Send(event GroupEvent) error {
+	client.outbound <- event
+	return nil
+}
+
+func (client *dummyGroupClient) Inbound() <-chan GroupEvent {
+	return client.inbound
+}
+
+func TestGroupDecoder(t *testing.T) {
+	client := newDummyGroupClient()
+
+	switchAddr := cemi.NewGroupAddr3(1, 0, 1)
+	tempAddr := cemi.NewGroupAddr3(1, 0, 2)
+	bogusAddr := cemi.NewGroupAddr3(1, 0, 3)
+	rawAddr := cemi.NewGroupAddr3(1, 0, 4)
+
+	decoder := NewGroupDecoder(client, map[cemi.GroupAddr]string{
+		switchAddr: "1.001",
+		tempAddr:   "9.001",
+		bogusAddr:  "0.000",
+	})
+
+	go func() {
+		client.inbound <- GroupEvent{Command: GroupWrite, Destination: switchAddr, Data: []byte{1}}
+		client.inbound <- GroupEvent{Command: GroupWrite, Destination: tempAddr, Data: []byte{1}}
+		client.inbound <- GroupEvent{Command: GroupWrite, Destination: bogusAddr, Data: []byte{1}}
+		client.inbound <- GroupEvent{Command: GroupWrite, Destination: rawAddr, Data: []byte{1}}
+		client.inbound <- GroupEvent{Command: GroupRead, Destination: switchAddr, Data: []byte{0}}
+		close(client.inbound)
+	}()
+
+	event := <-decoder.Inbound()
+	if value, ok := event.Value.(*dpt.DPT_1001); !ok || !bool(*value) || event.Err != nil || event.DPT != "1.001" {
+		t.Errorf("Unexpected decoded event: %+v", event)
+	}
+
+	event = <-decoder.Inbound()
+	if event.Value != nil || event.Err == nil {
+		t.Errorf("Decoding invalid data should fail: %+v", event)
+	}
+
+	event = <-decoder.Inbound()
+	if event.Value != nil || event.Err == nil {
+		t.Errorf("Decoding with unknown type should fail: %+v", event)
+	}
+
+	event = <-decoder.Inbound()
+	if event.Value != nil || event.Err != nil || event.DPT != "" || len(event.Data) != 1 {
+		t.Errorf("Unmapped event should carry raw data only: %+v", event)
+	}
+
+	event = <-decoder.Inbound()
+	if event.Value != nil || event.Err != nil || event.Command != GroupRead {
+		t.Errorf("Read request should not be decoded: %+v", event)
+	}
+
+	if _, ok := <-decoder.Inbound(); ok {
+		t.Error("Inbound channel should be closed")
+	}
+
+	value := dpt.DPT_1001(true)
+	if err := decoder.Write(switchAddr, &value); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent := <-client.outbound; sent.Command != GroupWrite || sent.Destination != switchAddr || sent.Data[0] != 1 {
+		t.Errorf("Unexpected outbound event: %+v", sent)
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
+	"github.com/vapourismo/knx-go/knx/util"
+)
+
+// ObjectFlags are the communication flags of a group object.
+type ObjectFlags uint8
+
+// These are the communication flags as known from ETS.
+const (
+	// FlagCommunication enables any communication of the object.
+	FlagCommunication ObjectFlags = 1 << iota
+
+	// FlagRead lets the object respond to GroupValueRead requests.
+	FlagRead
+
+	// FlagWrite lets GroupValueWrite requests update the object.
+	FlagWrite
+
+	// FlagTransmit makes the object send local changes.
+	FlagTransmit
+
+	// FlagUpdate lets GroupValueResponses update the object.
+	FlagUpdate
+
+	// FlagReadOnInit makes the object request its value during initialisation.
+	FlagReadOnInit
+)
+
+// String generates the usual ETS notation, e.g. "CRWT--".
+func (flags ObjectFlags) String() string {
+	buffer := []byte("CRWTUI")
+
+	for i := range buffer {
+		if flags&(1<<uint(i)) == 0 {
+			buffer[i] = '-'
+		}
+	}
+
+	return string(buffer)
+}
+
+// GroupObjectConfig describes a communication object.
+type GroupObjectConfig struct {
+	Name string
+
+	// Datapoint type name (e.g. "1.001")
+	DPT string
+
+	Flags ObjectFlags
+
+	// Associated group addresses. The first one is used for sending.
+	Addresses []cemi.GroupAddr
+
+	// OnUpdate is called when the value has been updated from the bus. It must not block.
+	OnUpdate func(obj *GroupObject, event GroupEvent)
+}
+
+// A GroupObject is a communication object of a virtual device.
+type GroupObject struct {
+	config GroupObjectConfig
+	device *VirtualDevice
+
+	// Protected by the device mutex
+	data        []byte
+	initPending bool
+}
+
+// Name returns the name of the object.
+func (obj *GroupObject) Name() string {
+	return obj.config.Name
+}
+
+// Flags returns the communication flags.
+func (obj *GroupObject) Flags() ObjectFlags {
+	return obj.config.Flags
+}
+
+// Addresses returns the associated group addresses.
+func (obj *GroupObject) Addresses() []cemi.GroupAddr {
+	return obj.config.Addresses
+}
+
+// Data returns a copy of the packed value, or nil if the object does not have a value yet.
+func (obj *GroupObject) Data() []byte {
+	obj.device.mu.Lock()
+	defer obj.device.mu.Unlock()
+
+	if obj.data == nil {
+		return nil
+	}
+
+	return append([]byte(nil), obj.data...)
+}
+
+// Value decodes the current value.
+func (obj *GroupObject) Value() (dpt.Datapoint, error) {
+	data := obj.Data()
+	if data == nil {
+		return nil, errObjectHasNoValue
+	}
+
+	value, _ := dpt.Produce(obj.config.DPT)
+	if err := value.Unpack(data); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// Set changes the value locally. It is transmitted if the object has the communication and
+// transmit flags. The value must be of the object's datapoint type.
+func (obj *GroupObject) Set(value dpt.DatapointValue) error {
+	expected, _ := dpt.Produce(obj.config.DPT)
+	if reflect.TypeOf(value) != reflect.TypeOf(expected) {
+		return fmt.Errorf("object %q expects datapoint type %s, got %T",
+			obj.config.Name, obj.config.DPT, value)
+	}
+
+	data := value.Pack()
+
+	obj.device.mu.Lock()
+	obj.data = data
+	obj.device.mu.Unlock()
+
+	if obj.config.Flags&(FlagCommunication|FlagTransmit) != FlagCommunication|FlagTransmit {
+		return nil
+	}
+
+	return obj.device.client.Send(GroupEvent{
+		Command:     GroupWrite,
+		Destination: obj.config.Addresses[0],
+		Data:        data,
+	})
+}
+
+var errObjectHasNoValue = errors.New("object does not have a value")
+
+// A VirtualDevice implements the group communication of a KNX device using communication objects.
+type VirtualDevice struct {
+	client GroupClient
+
+	mu      sync.Mutex
+	objects []*GroupObject
+	assoc   map[cemi.GroupAddr][]*GroupObject
+}
+
+// NewVirtualDevice creates a device which communicates using the given client. It consumes the
+// inbound events of the client.
+func NewVirtualDevice(client GroupClient) *VirtualDevice {
+	dev := &VirtualDevice{
+		client: client,
+		assoc:  make(map[cemi.GroupAddr][]*GroupObject),
+	}
+
+	go dev.serve()
+
+	return dev
+}
+
+// AddObject adds a communication object to the device.
+func (dev *VirtualDevice) AddObject(config GroupObjectConfig) (*GroupObject, error) {
+	if len(config.Addresses) == 0 {
+		return nil, fmt.Errorf("object %q has no group address", config.Name)
+	}
+
+	if _, ok := dpt.Produce(config.DPT); !ok {
+		return nil, fmt.Errorf("object %q has unknown datapoint type %q", config.Name, config.DPT)
+	}
+
+	obj := &GroupObject{
+		config: config,
+		device: dev,
+	}
+
+	// Keep the caller from modifying the association.
+	obj.config.Addresses = append([]cemi.GroupAddr(nil), config.Addresses...)
+
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+
+	dev.objects = append(dev.objects, obj)
+
+	for _, addr := range obj.config.Addresses {
+		dev.assoc[addr] = append(dev.assoc[addr], obj)
+	}
+
+	return obj, nil
+}
+
+// Objects returns the communication objects.
+func (dev *VirtualDevice) Objects() []*GroupObject {
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+
+	return append([]*GroupObject(nil), dev.objects...)
+}
+
+// Init sends GroupValueRead requests for all objects with the read-on-init flag. The responses
+// update the objects even if they lack the update flag.
+func (dev *VirtualDevice) Init() error {
+	var addrs []cemi.GroupAddr
+
+	dev.mu.Lock()
+	for _, obj := range dev.objects {
+		if obj.config.Flags&(FlagCommunication|FlagReadOnInit) == FlagCommunication|FlagReadOnInit {
+			obj.initPending = true
+			addrs = append(addrs, obj.config.Addresses[0])
+		}
+	}
+	dev.mu.Unlock()
+
+	for _, addr := range addrs {
+		if err := dev.client.Send(GroupEvent{Command: GroupRead, Destination: addr}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serve processes the inbound events of the client.
+func (dev *VirtualDevice) serve() {
+	for event := range dev.client.Inbound() {
+		if event.Command == GroupRead {
+			if err := dev.respond(event.Destination); err != nil {
+				util.Log(dev, "Failed to respond to read request for %v: %v", event.Destination, err)
+			}
+		} else {
+			dev.update(event)
+		}
+	}
+}
+
+// respond answers a read request using the first readable object with a value.
+func (dev *VirtualDevice) respond(addr cemi.GroupAddr) error {
+	var data []byte
+
+	dev.mu.Lock()
+	for _, obj := range dev.assoc[addr] {
+		if obj.config.Flags&(FlagCommunication|FlagRead) == FlagCommunication|FlagRead && obj.data != nil {
+			data = obj.data
+			break
+		}
+	}
+	dev.mu.Unlock()
+
+	if data == nil {
+		return nil
+	}
+
+	return dev.client.Send(GroupEvent{
+		Command:     GroupResponse,
+		Destination: addr,
+		Data:        data,
+	})
+}
+
+// update stores the value in the objects which accept it.
+func (dev *VirtualDevice) update(event GroupEvent) {
+	var updated []*GroupObject
+
+	dev.mu.Lock()
+	for _, obj := range dev.assoc[event.Destination] {
+		flags := obj.config.Flags
+		if flags&FlagCommunication == 0 {
+			continue
+		}
+
+		accept := false
+
+		switch event.Command {
+		case GroupWrite:
+			accept = flags&FlagWrite != 0
+
+		case GroupResponse:
+			accept = flags&FlagUpdate != 0 || obj.initPending
+		}
+
+		if accept {
+			obj.data = event.Data
+			obj.initPending = false
+			updated = append(updated, obj)
+		}
+	}
+	dev.mu.Unlock()
+
+	for _, obj := range updated {
+		if obj.config.OnUpdate != nil {
+			obj.config.OnUpdate(obj, event)
+		}
+	}
+}
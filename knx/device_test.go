@@ -0,0 +1,130 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
+)
+
+func TestObjectFlags_String(t *testing.T) {
+	if str := (FlagCommunication | FlagRead | FlagTransmit).String(); str != "CR-T--" {
+		t.Errorf("Unexpected string: %s", str)
+	}
+}
+
+func TestVirtualDevice(t *testing.T) {
+	client := newDummyGroupClient()
+	defer close(client.inbound)
+
+	dev := NewVirtualDevice(client)
+
+	switchAddr := cemi.NewGroupAddr3(1, 0, 1)
+	centralAddr := cemi.NewGroupAddr3(1, 0, 100)
+	statusAddr := cemi.NewGroupAddr3(1, 1, 1)
+
+	updates := make(chan GroupEvent, 10)
+
+	switchObj, err := dev.AddObject(GroupObjectConfig{
+		Name:      "Switch",
+		DPT:       "1.001",
+		Flags:     FlagCommunication | FlagWrite,
+		Addresses: []cemi.GroupAddr{switchAddr, centralAddr},
+		OnUpdate:  func(obj *GroupObject, event GroupEvent) { updates <- event },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusObj, err := dev.AddObject(GroupObjectConfig{
+		Name:      "Status",
+		DPT:       "1.001",
+		Flags:     FlagCommunication | FlagRead | FlagTransmit | FlagReadOnInit,
+		Addresses: []cemi.GroupAddr{statusAddr},
+		OnUpdate:  func(obj *GroupObject, event GroupEvent) { updates <- event },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dev.AddObject(GroupObjectConfig{Name: "Broken", DPT: "1.001"}); err == nil {
+		t.Error("Object without address should be rejected")
+	}
+
+	// Read on init
+	if err := dev.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	if event := <-client.outbound; event.Command != GroupRead || event.Destination != statusAddr {
+		t.Errorf("Unexpected init request: %+v", event)
+	}
+
+	client.inbound <- GroupEvent{Command: GroupResponse, Destination: statusAddr, Data: []byte{1}}
+	<-updates
+
+	// Subsequent responses are ignored without the update flag.
+	client.inbound <- GroupEvent{Command: GroupResponse, Destination: statusAddr, Data: []byte{0}}
+
+	// Write via the second associated address
+	client.inbound <- GroupEvent{Command: GroupWrite, Destination: centralAddr, Data: []byte{1}}
+	if event := <-updates; event.Destination != centralAddr {
+		t.Errorf("Unexpected update: %+v", event)
+	}
+
+	value, err := switchObj.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, ok := value.(*dpt.DPT_1001); !ok || !bool(*v) {
+		t.Errorf("Unexpected value: %v", value)
+	}
+
+	// Writes to a read-only object are ignored, reads are answered.
+	client.inbound <- GroupEvent{Command: GroupWrite, Destination: statusAddr, Data: []byte{0}}
+	client.inbound <- GroupEvent{Command: GroupRead, Destination: statusAddr}
+
+	if event := <-client.outbound; event.Command != GroupResponse || event.Data[0] != 1 {
+		t.Errorf("Unexpected response: %+v", event)
+	}
+
+	// The switch object is not readable.
+	client.inbound <- GroupEvent{Command: GroupRead, Destination: switchAddr}
+
+	// The returned data is a copy.
+	switchObj.Data()[0] = 0
+	if data := switchObj.Data(); data[0] != 1 {
+		t.Errorf("Object data has been modified: %v", data)
+	}
+
+	// Values of a different datapoint type are rejected.
+	percent := dpt.DPT_5001(50)
+	if err := statusObj.Set(&percent); err == nil {
+		t.Error("Value of a different datapoint type should be rejected")
+	}
+
+	// Local change
+	off := dpt.DPT_1001(false)
+	if err := statusObj.Set(&off); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-client.outbound:
+		if event.Command != GroupWrite || event.Destination != statusAddr || event.Data[0] != 0 {
+			t.Errorf("Unexpected transmission: %+v", event)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("Local change has not been transmitted")
+	}
+
+	if len(updates) != 0 {
+		t.Errorf("Unexpected updates: %d", len(updates))
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package dpt
+
+import "github.com/vapourismo/knx-go/knx/cemi"
+
+// NewValueFormatter creates a value formatter that decodes group telegrams using the datapoint
+// types (e.g. "9.001") that are assigned to the group addresses. Telegrams to addresses without a
+// known type or with data that does not match the type are left to the caller.
+func NewValueFormatter(types map[cemi.GroupAddr]string) cemi.ValueFormatter {
+	return func(dest cemi.GroupAddr, data []byte) (string, bool) {
+		name, ok := types[dest]
+		if !ok {
+			return "", false
+		}
+
+		value, ok := Produce(name)
+		if !ok || value.Unpack(data) != nil {
+			return "", false
+		}
+
+		return value.String(), true
+	}
+}
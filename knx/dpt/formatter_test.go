@@ -0,0 +1,27 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package dpt
+
+import (
+	"testing"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+func TestNewValueFormatter(t *testing.T) {
+	addr := cemi.NewGroupAddr3(1, 2, 3)
+	values := NewValueFormatter(map[cemi.GroupAddr]string{addr: "1.001"})
+
+	if value, ok := values(addr, []byte{1}); !ok || value != DPT_1001(true).String() {
+		t.Errorf("Unexpected value %q", value)
+	}
+
+	if _, ok := values(addr, []byte{0, 1, 2}); ok {
+		t.Error("Mismatching data should not be formatted")
+	}
+
+	if _, ok := values(cemi.NewGroupAddr3(1, 2, 4), []byte{1}); ok {
+		t.Error("Unknown address should not be formatted")
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package etsga
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+// Format is the layout of a CSV export.
+type Format uint8
+
+// These are the CSV layouts that ETS offers.
+const (
+	// Format1x1 contains one row with the full address per group address.
+	Format1x1 Format = iota
+
+	// Format3x1 additionally contains rows for the main and middle groups, e.g. "1/-/-".
+	Format3x1
+
+	// Format3x3 contains rows for the main and middle groups and one column per level, both for
+	// names and addresses.
+	Format3x3
+)
+
+// String generates a string representation.
+func (format Format) String() string {
+	switch format {
+	case Format1x1:
+		return "1/1"
+
+	case Format3x1:
+		return "3/1"
+
+	case Format3x3:
+		return "3/3"
+	}
+
+	return fmt.Sprintf("%#x", uint8(format))
+}
+
+// These are the column names used by ETS.
+var (
+	header1x1 = []string{"Group name", "Address", "Central", "Unfiltered", "Description", "DatapointType", "Security"}
+	header3x3 = []string{"Main", "Middle", "Sub", "Main", "Middle", "Sub", "Central", "Unfiltered", "Description", "DatapointType", "Security"}
+)
+
+var errNoHeader = errors.New("CSV export does not contain a header")
+
+// ReadCSV reads a CSV export in any of the layouts. The delimiter (semicolon, comma or tab) is
+// detected from the header, which must be present.
+func ReadCSV(r io.Reader) ([]GroupAddress, error) {
+	buffered := bufio.NewReader(r)
+
+	// Skip the byte order mark.
+	if bom, err := buffered.Peek(3); err == nil && bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		buffered.Discard(3)
+	}
+
+	// Peek returns fewer bytes if the input is short, which is fine for detecting the delimiter.
+	line, _ := buffered.Peek(4096)
+	if len(line) == 0 {
+		return nil, errNoHeader
+	}
+
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	switch {
+	case bytes.IndexByte(line, ';') >= 0:
+		reader.Comma = ';'
+
+	case bytes.IndexByte(line, '\t') >= 0:
+		reader.Comma = '\t'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errNoHeader
+	}
+
+	columns := make(map[string]int, len(header))
+	for i := len(header) - 1; i >= 0; i-- {
+		columns[strings.TrimSpace(header[i])] = i
+	}
+
+	if _, ok := columns["Sub"]; ok {
+		return readCSV3x3(reader, columns)
+	}
+
+	if _, ok := columns["Address"]; ok {
+		return readCSV1x1(reader, columns)
+	}
+
+	return nil, errNoHeader
+}
+
+// field retrieves the column with the given name.
+func field(record []string, columns map[string]int, name string) string {
+	if i, ok := columns[name]; ok && i < len(record) {
+		return strings.TrimSpace(record[i])
+	}
+
+	return ""
+}
+
+// readCSV1x1 reads the remaining records of the 1/1 and 3/1 layouts.
+func readCSV1x1(reader *csv.Reader, columns map[string]int) ([]GroupAddress, error) {
+	var (
+		addrs        []GroupAddress
+		main, middle string
+	)
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return addrs, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		name := field(record, columns, "Group name")
+		addrString := field(record, columns, "Address")
+
+		switch {
+		case strings.HasSuffix(addrString, "/-/-"):
+			main, middle = name, ""
+			continue
+
+		case strings.HasSuffix(addrString, "/-"):
+			middle = name
+			continue
+		}
+
+		addr, err := cemi.NewGroupAddrString(addrString)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		addrs = append(addrs, GroupAddress{
+			Address:     addr,
+			Name:        name,
+			Description: field(record, columns, "Description"),
+			Main:        main,
+			Middle:      middle,
+			DPT:         parseDatapointType(field(record, columns, "DatapointType")),
+		})
+	}
+}
+
+// readCSV3x3 reads the remaining records of the 3/3 layout, where the name columns come first and
+// the address columns second.
+func readCSV3x3(reader *csv.Reader, columns map[string]int) ([]GroupAddress, error) {
+	var (
+		addrs        []GroupAddress
+		main, middle string
+	)
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return addrs, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		if len(record) < 6 {
+			return nil, fmt.Errorf("line %d: too few columns", line)
+		}
+
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+
+		switch {
+		case record[0] != "":
+			main, middle = record[0], ""
+
+		case record[1] != "":
+			middle = record[1]
+
+		default:
+			nums := make([]uint8, 3)
+			for i := range nums {
+				num, err := strconv.ParseUint(record[3+i], 10, 8)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", line, err)
+				}
+
+				nums[i] = uint8(num)
+			}
+
+			addr, err := cemi.NewGroupAddrString(fmt.Sprintf("%d/%d/%d", nums[0], nums[1], nums[2]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+
+			addrs = append(addrs, GroupAddress{
+				Address:     addr,
+				Name:        record[2],
+				Description: field(record, columns, "Description"),
+				Main:        main,
+				Middle:      middle,
+				DPT:         parseDatapointType(field(record, columns, "DatapointType")),
+			})
+		}
+	}
+}
+
+// WriteCSV writes a CSV export in the given layout using semicolons as delimiter. Main and middle
+// group rows are generated whenever the group changes, therefore the addresses should be sorted.
+func WriteCSV(w io.Writer, format Format, addrs []GroupAddress) error {
+	if format > Format3x3 {
+		return fmt.Errorf("unknown format %v", format)
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+
+	if format == Format3x3 {
+		writer.Write(header3x3)
+	} else {
+		writer.Write(header1x1)
+	}
+
+	first := true
+	var lastMain, lastMiddle uint8
+
+	for _, addr := range addrs {
+		main, middle, sub := groupNumbers(addr.Address)
+		dptRef := ""
+		if addr.DPT != "" {
+			dptRef = formatDatapointType(addr.DPT)
+		}
+
+		newMain := first || main != lastMain
+		newMiddle := newMain || middle != lastMiddle
+		first, lastMain, lastMiddle = false, main, middle
+
+		switch format {
+		case Format1x1:
+			writer.Write([]string{addr.Name, addr.Address.String(), "", "", addr.Description, dptRef, "Auto"})
+
+		case Format3x1:
+			if newMain {
+				writer.Write([]string{addr.Main, fmt.Sprintf("%d/-/-", main), "", "", "", "", "Auto"})
+			}
+
+			if newMiddle {
+				writer.Write([]string{addr.Middle, fmt.Sprintf("%d/%d/-", main, middle), "", "", "", "", "Auto"})
+			}
+
+			writer.Write([]string{addr.Name, addr.Address.String(), "", "", addr.Description, dptRef, "Auto"})
+
+		case Format3x3:
+			mainString := strconv.Itoa(int(main))
+			middleString := strconv.Itoa(int(middle))
+
+			if newMain {
+				writer.Write([]string{addr.Main, "", "", mainString, "", "", "", "", "", "", "Auto"})
+			}
+
+			if newMiddle {
+				writer.Write([]string{"", addr.Middle, "", mainString, middleString, "", "", "", "", "", "Auto"})
+			}
+
+			writer.Write([]string{
+				"", "", addr.Name, mainString, middleString, strconv.Itoa(int(sub)),
+				"", "", addr.Description, dptRef, "Auto",
+			})
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
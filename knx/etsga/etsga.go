@@ -0,0 +1,74 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+// Package etsga reads and writes the group address export formats of ETS (CSV and XML).
+package etsga
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
+	"github.com/vapourismo/knx-go/knx/knxproj"
+)
+
+// A GroupAddress is an entry of a group address export.
+type GroupAddress struct {
+	Address     cemi.GroupAddr
+	Name        string
+	Description string
+
+	// Names of the main and middle group that contain the address
+	Main   string
+	Middle string
+
+	// Datapoint type name as used by the dpt package (e.g. "9.001"), empty if none is assigned
+	DPT string
+}
+
+// Datapoint creates a datapoint for the assigned type.
+func (ga *GroupAddress) Datapoint() (dpt.Datapoint, bool) {
+	if ga.DPT == "" {
+		return nil, false
+	}
+
+	return dpt.Produce(ga.DPT)
+}
+
+// DPTs returns the datapoint types of all group addresses that have one assigned.
+func DPTs(addrs []GroupAddress) map[cemi.GroupAddr]string {
+	types := make(map[cemi.GroupAddr]string)
+
+	for _, addr := range addrs {
+		if addr.DPT != "" {
+			types[addr.Address] = addr.DPT
+		}
+	}
+
+	return types
+}
+
+// parseDatapointType converts the DPT column or attribute of an export.
+func parseDatapointType(ref string) string {
+	// Multiple types are separated by commas in exports, but by spaces in projects.
+	name, _ := knxproj.ParseDatapointType(strings.Replace(ref, ",", " ", -1))
+	return name
+}
+
+// formatDatapointType converts a datapoint type name like "9.001" to its ETS reference
+// "DPST-9-1". Names that cannot be converted are returned unchanged.
+func formatDatapointType(name string) string {
+	var main, sub uint
+
+	if _, err := fmt.Sscanf(name, "%d.%d", &main, &sub); err != nil {
+		return name
+	}
+
+	return fmt.Sprintf("DPST-%d-%d", main, sub)
+}
+
+// groupNumbers splits a group address into its main, middle and sub group.
+func groupNumbers(addr cemi.GroupAddr) (uint8, uint8, uint8) {
+	return uint8(addr>>11) & 0x1F, uint8(addr>>8) & 0x7, uint8(addr)
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package etsga
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+var testAddresses = []GroupAddress{
+	{
+		Address:     cemi.NewGroupAddr3(1, 0, 1),
+		Name:        "Kitchen switch",
+		Description: "Ceiling",
+		Main:        "Lights",
+		Middle:      "Kitchen",
+		DPT:         "1.001",
+	},
+	{
+		Address: cemi.NewGroupAddr3(1, 0, 2),
+		Name:    "Kitchen dimmer",
+		Main:    "Lights",
+		Middle:  "Kitchen",
+		DPT:     "5.001",
+	},
+	{
+		Address: cemi.NewGroupAddr3(2, 1, 0),
+		Name:    "Outside temperature",
+		Main:    "Sensors",
+		Middle:  "Weather",
+		DPT:     "9.001",
+	},
+}
+
+const testCSV3x1 = "\xEF\xBB\xBF" + `"Group name";"Address";"Central";"Unfiltered";"Description";"DatapointType";"Security"
+"Lights";"1/-/-";"";"";"";"";"Auto"
+"Kitchen";"1/0/-";"";"";"";"";"Auto"
+"Kitchen switch";"1/0/1";"";"";"Ceiling";"DPST-1-1";"Auto"
+"Kitchen dimmer";"1/0/2";"";"";"";"DPT-5,DPST-5-1";"Auto"
+"Sensors";"2/-/-";"";"";"";"";"Auto"
+"Weather";"2/1/-";"";"";"";"";"Auto"
+"Outside temperature";"2/1/0";"";"";"";"DPST-9-1";"Auto"
+`
+
+const testCSV3x3 = `"Main","Middle","Sub","Main","Middle","Sub","Central","Unfiltered","Description","DatapointType","Security"
+"Lights","","","1","","","","","","","Auto"
+"","Kitchen","","1","0","","","","","","Auto"
+"","","Kitchen switch","1","0","1","","","Ceiling","DPST-1-1","Auto"
+"","","Kitchen dimmer","1","0","2","","","","DPST-5-1","Auto"
+"Sensors","","","2","","","","","","","Auto"
+"","Weather","","2","1","","","","","","Auto"
+"","","Outside temperature","2","1","0","","","","DPST-9-1","Auto"
+`
+
+func TestReadCSV(t *testing.T) {
+	for _, input := range []string{testCSV3x1, testCSV3x3} {
+		addrs, err := ReadCSV(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(addrs, testAddresses) {
+			t.Errorf("Mismatch:\n%+v\n%+v", addrs, testAddresses)
+		}
+	}
+
+	types := DPTs(testAddresses)
+	if len(types) != 3 || types[cemi.NewGroupAddr3(2, 1, 0)] != "9.001" {
+		t.Errorf("Unexpected datapoint types: %v", types)
+	}
+
+	if _, err := ReadCSV(strings.NewReader("")); err == nil {
+		t.Error("Should not succeed without header")
+	}
+
+	if _, err := ReadCSV(strings.NewReader("Group name;Address\nBroken;1/2/x\n")); err == nil {
+		t.Error("Should not succeed with invalid address")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	for _, format := range []Format{Format1x1, Format3x1, Format3x3} {
+		buffer := &bytes.Buffer{}
+		if err := WriteCSV(buffer, format, testAddresses); err != nil {
+			t.Fatal(err)
+		}
+
+		addrs, err := ReadCSV(buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := testAddresses
+		if format == Format1x1 {
+			// The 1/1 layout has no group names.
+			expected = make([]GroupAddress, len(testAddresses))
+			for i, addr := range testAddresses {
+				addr.Main, addr.Middle = "", ""
+				expected[i] = addr
+			}
+		}
+
+		if !reflect.DeepEqual(addrs, expected) {
+			t.Errorf("Mismatch in format %v:\n%+v\n%+v", format, addrs, expected)
+		}
+	}
+}
+
+func TestXML(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	if err := WriteXML(buffer, testAddresses); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buffer.String(), `<GroupAddress Name="Kitchen switch" Address="1/0/1" Description="Ceiling" DPTs="DPST-1-1">`) {
+		t.Errorf("Unexpected output:\n%s", buffer)
+	}
+
+	addrs, err := ReadXML(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(addrs, testAddresses) {
+		t.Errorf("Mismatch:\n%+v\n%+v", addrs, testAddresses)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package etsga
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+// xmlNamespace is the namespace of the group address export.
+const xmlNamespace = "http://knx.org/xml/ga-export/01"
+
+type (
+	xmlGroupAddress struct {
+		Name        string `xml:"Name,attr"`
+		Address     string `xml:"Address,attr"`
+		Description string `xml:"Description,attr,omitempty"`
+		DPTs        string `xml:"DPTs,attr,omitempty"`
+	}
+
+	xmlGroupRange struct {
+		Name       string            `xml:"Name,attr"`
+		RangeStart uint16            `xml:"RangeStart,attr"`
+		RangeEnd   uint16            `xml:"RangeEnd,attr"`
+		Ranges     []*xmlGroupRange  `xml:"GroupRange"`
+		Addresses  []xmlGroupAddress `xml:"GroupAddress"`
+	}
+
+	xmlExport struct {
+		XMLName xml.Name         `xml:"GroupAddress-Export"`
+		XMLNS   string           `xml:"xmlns,attr,omitempty"`
+		Ranges  []*xmlGroupRange `xml:"GroupRange"`
+	}
+)
+
+// ReadXML reads an XML export.
+func ReadXML(r io.Reader) ([]GroupAddress, error) {
+	var export xmlExport
+	if err := xml.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+
+	var addrs []GroupAddress
+
+	for _, main := range export.Ranges {
+		var err error
+
+		if addrs, err = appendXMLAddresses(addrs, main.Addresses, main.Name, ""); err != nil {
+			return nil, err
+		}
+
+		for _, middle := range main.Ranges {
+			if addrs, err = appendXMLAddresses(addrs, middle.Addresses, main.Name, middle.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return addrs, nil
+}
+
+// appendXMLAddresses converts the addresses of a group range.
+func appendXMLAddresses(addrs []GroupAddress, elems []xmlGroupAddress, main, middle string) ([]GroupAddress, error) {
+	for _, elem := range elems {
+		addr, err := cemi.NewGroupAddrString(elem.Address)
+		if err != nil {
+			return nil, fmt.Errorf("group address %q: %v", elem.Name, err)
+		}
+
+		addrs = append(addrs, GroupAddress{
+			Address:     addr,
+			Name:        elem.Name,
+			Description: elem.Description,
+			Main:        main,
+			Middle:      middle,
+			DPT:         parseDatapointType(elem.DPTs),
+		})
+	}
+
+	return addrs, nil
+}
+
+// WriteXML writes an XML export with a three-level hierarchy. The main and middle group names
+// are taken from the first address in each group.
+func WriteXML(w io.Writer, addrs []GroupAddress) error {
+	export := xmlExport{XMLNS: xmlNamespace}
+
+	mains := make(map[uint8]*xmlGroupRange)
+	middles := make(map[[2]uint8]*xmlGroupRange)
+
+	for _, addr := range addrs {
+		main, middle, _ := groupNumbers(addr.Address)
+
+		mainRange, ok := mains[main]
+		if !ok {
+			start := uint16(main) << 11
+			mainRange = &xmlGroupRange{Name: addr.Main, RangeStart: start, RangeEnd: start | 0x7FF}
+			mains[main] = mainRange
+			export.Ranges = append(export.Ranges, mainRange)
+		}
+
+		middleRange, ok := middles[[2]uint8{main, middle}]
+		if !ok {
+			start := uint16(main)<<11 | uint16(middle)<<8
+			middleRange = &xmlGroupRange{Name: addr.Middle, RangeStart: start, RangeEnd: start | 0xFF}
+			middles[[2]uint8{main, middle}] = middleRange
+			mainRange.Ranges = append(mainRange.Ranges, middleRange)
+		}
+
+		elem := xmlGroupAddress{
+			Name:        addr.Name,
+			Address:     addr.Address.String(),
+			Description: addr.Description,
+		}
+
+		if addr.DPT != "" {
+			elem.DPTs = formatDatapointType(addr.DPT)
+		}
+
+		middleRange.Addresses = append(middleRange.Addresses, elem)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	return encoder.Encode(&export)
+}
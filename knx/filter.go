@@ -0,0 +1,190 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/util"
+)
+
+// InboundFilterConfig allows you to configure the filtering of inbound L_Data frames.
+type InboundFilterConfig struct {
+	// DuplicateWindow is the time during which repetitions of a frame are dropped. Only frames with
+	// the repeat flag are repetitions; identical frames without it are new actions, e.g. pressing
+	// the same button twice. Frames are compared without additional info, repeat flag and hop
+	// count. 0 disables deduplication.
+	DuplicateWindow time.Duration
+
+	// SuppressEcho drops frames that we have sent ourselves within EchoWindow. Frames sent without
+	// a source address are expected to come back with the individual address of the tunnel.
+	SuppressEcho bool
+
+	// EchoWindow is the time during which an echo of a sent frame is expected.
+	EchoWindow time.Duration
+}
+
+// DefaultInboundFilterConfig is a good default configuration for an inbound filter.
+var DefaultInboundFilterConfig = InboundFilterConfig{
+	DuplicateWindow: 500 * time.Millisecond,
+	SuppressEcho:    true,
+	EchoWindow:      2 * time.Second,
+}
+
+// checkInboundFilterConfig makes sure that the configuration is actually usable.
+func checkInboundFilterConfig(config InboundFilterConfig) InboundFilterConfig {
+	if config.SuppressEcho && config.EchoWindow <= 0 {
+		config.EchoWindow = DefaultInboundFilterConfig.EchoWindow
+	}
+
+	return config
+}
+
+// sentFrame is a frame that has been transmitted by us.
+type sentFrame struct {
+	source cemi.IndividualAddr
+	time   time.Time
+}
+
+// inboundFilter drops duplicate frames and echoes of our own frames.
+type inboundFilter struct {
+	config InboundFilterConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	localAddr cemi.IndividualAddr
+	seen      map[string]time.Time
+	sent      map[string][]sentFrame
+}
+
+// newInboundFilter creates a filter.
+func newInboundFilter(config InboundFilterConfig) *inboundFilter {
+	return &inboundFilter{
+		config: checkInboundFilterConfig(config),
+		now:    time.Now,
+		seen:   make(map[string]time.Time),
+		sent:   make(map[string][]sentFrame),
+	}
+}
+
+// frameContent extracts the L_Data frame of the message and generates a key which identifies its
+// content. Only frame type and priority of the first control field are considered, since the other
+// flags may be altered along the way. Source, additional info and hop count are ignored as well.
+func frameContent(msg cemi.Message) (*cemi.LData, string, bool) {
+	var ldata *cemi.LData
+
+	switch msg := msg.(type) {
+	case *cemi.LDataReq:
+		ldata = &msg.LData
+
+	case *cemi.LDataInd:
+		ldata = &msg.LData
+
+	default:
+		return nil, "", false
+	}
+
+	if ldata.Data == nil {
+		return nil, "", false
+	}
+
+	normalized := *ldata
+	normalized.Info = nil
+	normalized.Source = 0
+	normalized.Control1 = ldata.Control1&cemi.Control1StdFrame | cemi.Control1Prio(ldata.Control1.Priority())
+	normalized.Control2.SetHops(0)
+
+	return ldata, string(util.AllocAndPack(&normalized)), true
+}
+
+// expire removes entries that are older than the respective window.
+func (filter *inboundFilter) expire(now time.Time) {
+	for key, last := range filter.seen {
+		if now.Sub(last) > filter.config.DuplicateWindow {
+			delete(filter.seen, key)
+		}
+	}
+
+	for key, frames := range filter.sent {
+		for len(frames) > 0 && now.Sub(frames[0].time) > filter.config.EchoWindow {
+			frames = frames[1:]
+		}
+
+		if len(frames) == 0 {
+			delete(filter.sent, key)
+		} else {
+			filter.sent[key] = frames
+		}
+	}
+}
+
+// setLocalAddr sets the individual address that the gateway assigns to frames which we send
+// without a source address.
+func (filter *inboundFilter) setLocalAddr(addr cemi.IndividualAddr) {
+	filter.mu.Lock()
+	filter.localAddr = addr
+	filter.mu.Unlock()
+}
+
+// recordSent remembers a frame that we are about to transmit.
+func (filter *inboundFilter) recordSent(msg cemi.Message) {
+	if !filter.config.SuppressEcho {
+		return
+	}
+
+	ldata, key, ok := frameContent(msg)
+	if !ok {
+		return
+	}
+
+	filter.mu.Lock()
+	defer filter.mu.Unlock()
+
+	source := ldata.Source
+	if source == 0 {
+		source = filter.localAddr
+	}
+
+	now := filter.now()
+	filter.expire(now)
+	filter.sent[key] = append(filter.sent[key], sentFrame{source: source, time: now})
+}
+
+// drop determines whether the inbound message shall be dropped.
+func (filter *inboundFilter) drop(msg cemi.Message) bool {
+	ldata, key, ok := frameContent(msg)
+	if !ok {
+		return false
+	}
+
+	filter.mu.Lock()
+	defer filter.mu.Unlock()
+
+	now := filter.now()
+	filter.expire(now)
+
+	if filter.config.DuplicateWindow > 0 {
+		sourceKey := string([]byte{byte(ldata.Source >> 8), byte(ldata.Source)}) + key
+
+		// The repeat flag of an inbound frame is cleared if it is a repetition.
+		if _, ok := filter.seen[sourceKey]; ok && ldata.Control1.Repeat() {
+			return true
+		}
+
+		filter.seen[sourceKey] = now
+	}
+
+	// Other devices never send without a source address, hence such a frame is always our own.
+	for i, frame := range filter.sent[key] {
+		if frame.source == ldata.Source || ldata.Source == 0 {
+			frames := filter.sent[key]
+			filter.sent[key] = append(frames[:i:i], frames[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
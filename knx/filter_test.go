@@ -0,0 +1,108 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+func TestInboundFilter(t *testing.T) {
+	filter := newInboundFilter(InboundFilterConfig{
+		DuplicateWindow: 100 * time.Millisecond,
+		SuppressEcho:    true,
+		EchoWindow:      time.Second,
+	})
+
+	now := time.Now()
+	filter.now = func() time.Time { return now }
+
+	source := cemi.NewIndividualAddr3(1, 1, 5)
+	ldata := buildGroupOutbound(GroupEvent{
+		Command:     GroupWrite,
+		Source:      source,
+		Destination: cemi.NewGroupAddr3(1, 2, 3),
+		Data:        []byte{1},
+	})
+
+	if filter.drop(&cemi.LDataInd{LData: ldata}) {
+		t.Error("First frame should pass")
+	}
+
+	// Repetition with repeat flag and decremented hop count
+	repeated := ldata
+	repeated.Control1.SetRepeat(true)
+	repeated.Control2.SetHops(5)
+
+	now = now.Add(50 * time.Millisecond)
+	if !filter.drop(&cemi.LDataInd{LData: repeated}) {
+		t.Error("Repetition should be dropped")
+	}
+
+	other := ldata
+	other.Source = cemi.NewIndividualAddr3(1, 1, 6)
+	if filter.drop(&cemi.LDataInd{LData: other}) {
+		t.Error("Frame from another source should pass")
+	}
+
+	// The same action again, e.g. a button pressed twice
+	if filter.drop(&cemi.LDataInd{LData: ldata}) {
+		t.Error("Identical frame without repeat flag should pass")
+	}
+
+	now = now.Add(150 * time.Millisecond)
+	if filter.drop(&cemi.LDataInd{LData: repeated}) {
+		t.Error("Repetition should pass after the window")
+	}
+
+	// Echo of a frame sent without source address
+	gateway := cemi.NewIndividualAddr3(1, 1, 250)
+	filter.setLocalAddr(gateway)
+
+	now = now.Add(time.Second)
+	sent := ldata
+	sent.Source = 0
+	sent.Data = &cemi.AppData{Command: cemi.GroupValueWrite, Data: []byte{2}}
+	filter.recordSent(&cemi.LDataReq{LData: sent})
+	filter.recordSent(&cemi.LDataReq{LData: sent})
+
+	foreign := sent
+	foreign.Source = cemi.NewIndividualAddr3(1, 1, 7)
+	if filter.drop(&cemi.LDataInd{LData: foreign}) {
+		t.Error("Identical frame from another device should pass")
+	}
+
+	echo := sent
+	echo.Source = gateway
+	if !filter.drop(&cemi.LDataInd{LData: echo}) {
+		t.Error("Echo should be dropped")
+	}
+
+	// Echoes might keep the empty source address.
+	if !filter.drop(&cemi.LDataInd{LData: sent}) {
+		t.Error("Echo without source address should be dropped")
+	}
+
+	// Both echoes have been consumed, but repetitions are still duplicates.
+	if filter.drop(&cemi.LDataInd{LData: echo}) {
+		t.Error("Frame should pass after its echoes")
+	}
+
+	repeatedEcho := echo
+	repeatedEcho.Control1.SetRepeat(true)
+	if !filter.drop(&cemi.LDataInd{LData: repeatedEcho}) {
+		t.Error("Repetition of the frame should be dropped")
+	}
+
+	now = now.Add(time.Second)
+	if filter.drop(&cemi.LDataInd{LData: repeatedEcho}) {
+		t.Error("Frame should pass after the window")
+	}
+
+	if filter.drop(&cemi.LDataCon{LData: echo}) {
+		t.Error("Other messages should pass")
+	}
+}
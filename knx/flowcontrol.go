@@ -0,0 +1,190 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/knxnet"
+)
+
+// FlowControlConfig allows you to configure how a Router signals congestion of its inbound queue
+// to the other routers.
+type FlowControlConfig struct {
+	// QueueSize is the number of inbound frames that are buffered. Frames that do not fit are
+	// dropped and reported using ROUTING_LOST_MESSAGE. At most one such message is sent per
+	// BusyWaitTime, carrying the number of frames dropped in the meantime.
+	QueueSize uint
+
+	// BusyThreshold is the number of queued frames from which on ROUTING_BUSY is sent.
+	BusyThreshold uint
+
+	// BusyWaitTime is the wait time announced in ROUTING_BUSY. The specification demands a value
+	// between 20 ms and 100 ms.
+	BusyWaitTime time.Duration
+}
+
+// DefaultFlowControlConfig is a good default configuration for flow control.
+var DefaultFlowControlConfig = FlowControlConfig{
+	QueueSize:     64,
+	BusyThreshold: 48,
+	BusyWaitTime:  100 * time.Millisecond,
+}
+
+// These are the limits of the wait time in ROUTING_BUSY.
+const (
+	minBusyWaitTime = 20 * time.Millisecond
+	maxBusyWaitTime = 100 * time.Millisecond
+)
+
+// checkFlowControlConfig makes sure that the configuration is actually usable.
+func checkFlowControlConfig(config FlowControlConfig) FlowControlConfig {
+	if config.QueueSize == 0 {
+		config.QueueSize = DefaultFlowControlConfig.QueueSize
+	}
+
+	if config.BusyThreshold == 0 || config.BusyThreshold > config.QueueSize {
+		config.BusyThreshold = config.QueueSize * 3 / 4
+		if config.BusyThreshold == 0 {
+			config.BusyThreshold = 1
+		}
+	}
+
+	if config.BusyWaitTime == 0 {
+		config.BusyWaitTime = DefaultFlowControlConfig.BusyWaitTime
+	} else if config.BusyWaitTime < minBusyWaitTime {
+		config.BusyWaitTime = minBusyWaitTime
+	} else if config.BusyWaitTime > maxBusyWaitTime {
+		config.BusyWaitTime = maxBusyWaitTime
+	}
+
+	return config
+}
+
+// These are the timing parameters of the routing busy back-off.
+const (
+	// Busy indications within this interval count as a single congestion event.
+	busyCoalesceInterval = 10 * time.Millisecond
+
+	// Maximum random wait per busy count
+	busyRandomWaitUnit = 50 * time.Millisecond
+
+	// Duration per busy count during which the counter is kept after sending has resumed
+	busySlowDurationUnit = 100 * time.Millisecond
+
+	// Interval in which the busy counter is decremented after the slow duration
+	busyDecrementInterval = 5 * time.Millisecond
+)
+
+// busyBackoff implements the reaction to ROUTING_BUSY indications as specified for KNXnet/IP
+// routing. Each indication pauses sending for its wait time plus a random time of up to N * 50 ms,
+// where N counts the recent indications. N is kept for t_slowduration = N * 100 ms after sending
+// resumes and is decremented every 5 ms thereafter.
+type busyBackoff struct {
+	now    func() time.Time
+	random func() float64
+
+	mu            sync.Mutex
+	counter       uint
+	lastIncrement time.Time
+	resume        time.Time
+	slowUntil     time.Time
+	senders       map[string]time.Time
+}
+
+// newBusyBackoff creates a back-off using the system clock.
+func newBusyBackoff() *busyBackoff {
+	return &busyBackoff{
+		now:     time.Now,
+		random:  rand.Float64,
+		senders: make(map[string]time.Time),
+	}
+}
+
+// decay decrements the busy counter once the slow duration has elapsed.
+func (backoff *busyBackoff) decay(now time.Time) {
+	if backoff.counter == 0 || now.Before(backoff.slowUntil) {
+		return
+	}
+
+	steps := 1 + uint(now.Sub(backoff.slowUntil)/busyDecrementInterval)
+	if steps >= backoff.counter {
+		backoff.counter = 0
+		return
+	}
+
+	backoff.counter -= steps
+	backoff.slowUntil = backoff.slowUntil.Add(time.Duration(steps) * busyDecrementInterval)
+}
+
+// busy processes a busy indication.
+func (backoff *busyBackoff) busy(msg *knxnet.RoutingBusy) {
+	backoff.mu.Lock()
+	defer backoff.mu.Unlock()
+
+	now := backoff.now()
+	backoff.decay(now)
+
+	waitTime := msg.WaitTime
+	if waitTime > maxBusyWaitTime {
+		waitTime = maxBusyWaitTime
+	}
+
+	var sender string
+	if msg.Sender != nil {
+		sender = msg.Sender.String()
+	}
+
+	for key, end := range backoff.senders {
+		if !now.Before(end) {
+			delete(backoff.senders, key)
+		}
+	}
+
+	// Repeated indications of a sender during its own wait time only extend the pause. Indications
+	// with a non-zero control field are not addressed at all devices and do not count either.
+	_, waiting := backoff.senders[sender]
+	backoff.senders[sender] = now.Add(waitTime)
+
+	if !waiting && msg.Control == 0 && now.Sub(backoff.lastIncrement) > busyCoalesceInterval {
+		backoff.counter++
+		backoff.lastIncrement = now
+	}
+
+	resume := now.Add(waitTime)
+	if msg.Control == 0 {
+		random := backoff.random() * float64(backoff.counter) * float64(busyRandomWaitUnit)
+		resume = resume.Add(time.Duration(random))
+	}
+
+	if resume.After(backoff.resume) {
+		backoff.resume = resume
+	}
+
+	backoff.slowUntil = backoff.resume.Add(time.Duration(backoff.counter) * busySlowDurationUnit)
+}
+
+// delay returns the time to wait before the next frame may be sent.
+func (backoff *busyBackoff) delay() time.Duration {
+	backoff.mu.Lock()
+	defer backoff.mu.Unlock()
+
+	now := backoff.now()
+	backoff.decay(now)
+
+	if now.Before(backoff.resume) {
+		return backoff.resume.Sub(now)
+	}
+
+	return 0
+}
+
+// wait blocks until frames may be sent again.
+func (backoff *busyBackoff) wait() {
+	for delay := backoff.delay(); delay > 0; delay = backoff.delay() {
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/knxnet"
+)
+
+func newTestBackoff(now *time.Time) *busyBackoff {
+	backoff := newBusyBackoff()
+	backoff.now = func() time.Time { return *now }
+	backoff.random = func() float64 { return 0.5 }
+
+	return backoff
+}
+
+func makeBusy(sender byte, waitTime time.Duration) *knxnet.RoutingBusy {
+	return &knxnet.RoutingBusy{
+		WaitTime: waitTime,
+		Sender:   &net.UDPAddr{IP: net.IPv4(192, 168, 1, sender), Port: 3671},
+	}
+}
+
+func TestBusyBackoff(t *testing.T) {
+	now := time.Unix(0, 0)
+	backoff := newTestBackoff(&now)
+
+	if delay := backoff.delay(); delay != 0 {
+		t.Errorf("Unexpected delay %v without busy indication", delay)
+	}
+
+	// N = 1: 100 ms wait time plus 0.5 * 50 ms
+	backoff.busy(makeBusy(1, 100*time.Millisecond))
+	if delay := backoff.delay(); delay != 125*time.Millisecond || backoff.counter != 1 {
+		t.Errorf("Unexpected delay %v with counter %d", delay, backoff.counter)
+	}
+
+	// Indications from other senders within 10 ms belong to the same event.
+	now = now.Add(5 * time.Millisecond)
+	backoff.busy(makeBusy(2, 100*time.Millisecond))
+	if backoff.counter != 1 {
+		t.Errorf("Unexpected counter %d", backoff.counter)
+	}
+
+	// Repetitions by a sender during its wait time only extend the pause.
+	now = now.Add(50 * time.Millisecond)
+	backoff.busy(makeBusy(1, 100*time.Millisecond))
+	if delay := backoff.delay(); delay != 125*time.Millisecond || backoff.counter != 1 {
+		t.Errorf("Unexpected delay %v with counter %d", delay, backoff.counter)
+	}
+
+	// N = 2: 20 ms wait time plus 0.5 * 100 ms, but the previous pause lasts longer
+	now = now.Add(20 * time.Millisecond)
+	backoff.busy(makeBusy(3, 20*time.Millisecond))
+	if delay := backoff.delay(); delay != 105*time.Millisecond || backoff.counter != 2 {
+		t.Errorf("Unexpected delay %v with counter %d", delay, backoff.counter)
+	}
+
+	// Sending resumes at 180 ms. The counter is kept for 2 * 100 ms.
+	now = time.Unix(0, 0).Add(379 * time.Millisecond)
+	if delay := backoff.delay(); delay != 0 || backoff.counter != 2 {
+		t.Errorf("Unexpected delay %v with counter %d", delay, backoff.counter)
+	}
+
+	// Afterwards it is decremented every 5 ms.
+	now = now.Add(1 * time.Millisecond)
+	if backoff.delay(); backoff.counter != 1 {
+		t.Errorf("Unexpected counter %d", backoff.counter)
+	}
+
+	now = now.Add(5 * time.Millisecond)
+	if backoff.delay(); backoff.counter != 0 {
+		t.Errorf("Unexpected counter %d", backoff.counter)
+	}
+}
+
+func TestBusyBackoff_Control(t *testing.T) {
+	now := time.Unix(0, 0)
+	backoff := newTestBackoff(&now)
+
+	busy := makeBusy(1, time.Second)
+	busy.Control = 1
+	backoff.busy(busy)
+
+	if delay := backoff.delay(); delay != maxBusyWaitTime || backoff.counter != 0 {
+		t.Errorf("Unexpected delay %v with counter %d", delay, backoff.counter)
+	}
+}
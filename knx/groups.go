@@ -4,7 +4,13 @@
 package knx
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
 	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
 	"github.com/vapourismo/knx-go/knx/util"
 )
 
@@ -48,37 +54,192 @@ type GroupClient interface {
 	Inbound() <-chan GroupEvent
 }
 
-// serveGroupInbound serves a group communication.
-func serveGroupInbound(inbound <-chan cemi.Message, outbound chan<- GroupEvent) {
+// groupReaders keeps track of the callers which wait for a GroupValueResponse.
+type groupReaders struct {
+	mu      sync.Mutex
+	waiting map[cemi.GroupAddr]map[chan GroupEvent]struct{}
+}
+
+// newGroupReaders creates an empty set of readers.
+func newGroupReaders() *groupReaders {
+	return &groupReaders{waiting: make(map[cemi.GroupAddr]map[chan GroupEvent]struct{})}
+}
+
+// wait registers a reader for the given address.
+func (readers *groupReaders) wait(addr cemi.GroupAddr) chan GroupEvent {
+	readers.mu.Lock()
+	defer readers.mu.Unlock()
+
+	responses := make(chan GroupEvent, 1)
+
+	if readers.waiting[addr] == nil {
+		readers.waiting[addr] = make(map[chan GroupEvent]struct{})
+	}
+
+	readers.waiting[addr][responses] = struct{}{}
+
+	return responses
+}
+
+// cancel unregisters a reader.
+func (readers *groupReaders) cancel(addr cemi.GroupAddr, responses chan GroupEvent) {
+	readers.mu.Lock()
+	defer readers.mu.Unlock()
+
+	delete(readers.waiting[addr], responses)
+
+	if len(readers.waiting[addr]) == 0 {
+		delete(readers.waiting, addr)
+	}
+}
+
+// deliver hands a response to the readers waiting for its destination.
+func (readers *groupReaders) deliver(event GroupEvent) {
+	readers.mu.Lock()
+	defer readers.mu.Unlock()
+
+	for responses := range readers.waiting[event.Destination] {
+		// Readers only care about the first response.
+		select {
+		case responses <- event:
+		default:
+		}
+	}
+}
+
+var errGroupReadTimeout = errors.New("no response to group read")
+
+// readGroup sends a GroupValueRead and waits for the first response. The request is repeated
+// after each timeout until the given number of attempts has been made.
+func readGroup(
+	ctx context.Context,
+	client GroupClient,
+	readers *groupReaders,
+	addr cemi.GroupAddr,
+	timeout time.Duration,
+	attempts uint,
+) (GroupEvent, error) {
+	responses := readers.wait(addr)
+	defer readers.cancel(addr, responses)
+
+	for i := uint(0); i < attempts; i++ {
+		if err := client.Send(GroupEvent{Command: GroupRead, Destination: addr}); err != nil {
+			return GroupEvent{}, err
+		}
+
+		timer := time.NewTimer(timeout)
+
+		select {
+		case event := <-responses:
+			timer.Stop()
+			return event, nil
+
+		case <-timer.C:
+
+		case <-ctx.Done():
+			timer.Stop()
+			return GroupEvent{}, ctx.Err()
+		}
+	}
+
+	return GroupEvent{}, errGroupReadTimeout
+}
+
+// writeValue sends a group event carrying the packed value.
+func writeValue(client GroupClient, cmd GroupCommand, addr cemi.GroupAddr, value dpt.DatapointValue) error {
+	return client.Send(GroupEvent{
+		Command:     cmd,
+		Destination: addr,
+		Data:        value.Pack(),
+	})
+}
+
+// groupInboundBacklog is the number of events which are kept while the inbound channel of a group
+// client is not consumed. The oldest events are dropped once it is exceeded.
+const groupInboundBacklog = 256
+
+// serveGroupInbound serves a group communication. Responses are also handed to the readers. The
+// worker does not wait for the outbound channel to be consumed, so that readers are served
+// regardless.
+func serveGroupInbound(inbound <-chan cemi.Message, outbound chan<- GroupEvent, readers *groupReaders) {
 	util.Log(inbound, "Started worker")
 	defer util.Log(inbound, "Worker exited")
 
-	for msg := range inbound {
-		if ind, ok := msg.(*cemi.LDataInd); ok {
-			// Filter indications that do not target group addresses.
-			if !ind.Control2.IsGroupAddr() {
-				util.Log(inbound, "Received L_Data.ind does not target a group address")
+	var pending []GroupEvent
+
+	for source := inbound; source != nil; {
+		// Only offer an event if there is one.
+		var next chan<- GroupEvent
+		var head GroupEvent
+
+		if len(pending) > 0 {
+			next = outbound
+			head = pending[0]
+		}
+
+		select {
+		case msg, open := <-source:
+			if !open {
+				source = nil
+				continue
+			}
+
+			event, ok := groupEventOf(inbound, msg)
+			if !ok {
 				continue
 			}
 
-			if app, ok := ind.Data.(*cemi.AppData); ok && app.Command.IsGroupCommand() {
-				outbound <- GroupEvent{
-					Command:     GroupCommand(app.Command),
-					Source:      ind.Source,
-					Destination: cemi.GroupAddr(ind.Destination),
-					Data:        app.Data,
-				}
-			} else {
-				util.Log(inbound, "Received L_Data.ind frame does not contain application data")
+			if event.Command == GroupResponse {
+				readers.deliver(event)
 			}
-		} else {
-			util.Log(inbound, "Received frame is not a L_Data.ind frame")
+
+			if len(pending) >= groupInboundBacklog {
+				util.Log(inbound, "Inbound events are not consumed, dropping %+v", pending[0])
+				pending = pending[1:]
+			}
+
+			pending = append(pending, event)
+
+		case next <- head:
+			pending = pending[1:]
 		}
 	}
 
+	for _, event := range pending {
+		outbound <- event
+	}
+
 	close(outbound)
 }
 
+// groupEventOf extracts the group event from an inbound message.
+func groupEventOf(inbound <-chan cemi.Message, msg cemi.Message) (GroupEvent, bool) {
+	ind, ok := msg.(*cemi.LDataInd)
+	if !ok {
+		util.Log(inbound, "Received frame is not a L_Data.ind frame")
+		return GroupEvent{}, false
+	}
+
+	// Filter indications that do not target group addresses.
+	if !ind.Control2.IsGroupAddr() {
+		util.Log(inbound, "Received L_Data.ind does not target a group address")
+		return GroupEvent{}, false
+	}
+
+	app, ok := ind.Data.(*cemi.AppData)
+	if !ok || !app.Command.IsGroupCommand() {
+		util.Log(inbound, "Received L_Data.ind frame does not contain application data")
+		return GroupEvent{}, false
+	}
+
+	return GroupEvent{
+		Command:     GroupCommand(app.Command),
+		Source:      ind.Source,
+		Destination: cemi.GroupAddr(ind.Destination),
+		Data:        app.Data,
+	}, true
+}
+
 var defaultGroupLData = cemi.LData{
 	Control1: cemi.Control1NoRepeat | cemi.Control1NoSysBroadcast | cemi.Control1WantAck | cemi.Control1Prio(cemi.PrioLow),
 	Control2: cemi.Control2GroupAddr | cemi.Control2Hops(6),
@@ -94,9 +255,21 @@ func buildGroupOutbound(event GroupEvent) cemi.LData {
 	ldata.Source = event.Source
 	ldata.Destination = uint16(event.Destination)
 
-	if len(event.Data) <= 15 {
-		ldata.Control1 |= cemi.Control1StdFrame
-	}
+	ldata.SetFrameType()
 
 	return ldata
 }
+
+// validateOutbound makes sure that outgoing L_Data frames fit into the maximum APDU length.
+func validateOutbound(data cemi.Message, maxAPDULength uint) error {
+	switch msg := data.(type) {
+	case *cemi.LDataReq:
+		return msg.Validate(maxAPDULength)
+
+	case *cemi.LDataInd:
+		return msg.Validate(maxAPDULength)
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
+)
+
+func TestReadGroup(t *testing.T) {
+	client := newDummyGroupClient()
+	inbound := make(chan cemi.Message)
+	outbound := make(chan GroupEvent)
+	readers := newGroupReaders()
+
+	go serveGroupInbound(inbound, outbound, readers)
+	defer close(inbound)
+
+	addr := cemi.NewGroupAddr3(1, 2, 3)
+	source := cemi.NewIndividualAddr3(1, 1, 20)
+
+	go func() {
+		// Ignore the first request, answer the second one.
+		for i := 0; i < 2; i++ {
+			if event := <-client.outbound; event.Command != GroupRead || event.Destination != addr {
+				t.Errorf("Unexpected request: %+v", event)
+			}
+		}
+
+		ldata := buildGroupOutbound(GroupEvent{Command: GroupResponse, Source: source, Destination: addr, Data: []byte{7}})
+		inbound <- &cemi.LDataInd{LData: ldata}
+
+		// Other consumers still receive the response.
+		if event := <-outbound; event.Command != GroupResponse {
+			t.Errorf("Unexpected inbound event: %+v", event)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	event, err := readGroup(ctx, client, readers, addr, 20*time.Millisecond, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if event.Source != source || !bytes.Equal(event.Data, []byte{7}) {
+		t.Errorf("Unexpected response: %+v", event)
+	}
+
+	if len(readers.waiting) != 0 {
+		t.Error("Reader has not been removed")
+	}
+
+	// No response at all.
+	go func() {
+		for i := 0; i < 2; i++ {
+			<-client.outbound
+		}
+	}()
+
+	if _, err := readGroup(ctx, client, readers, addr, 10*time.Millisecond, 2); err != errGroupReadTimeout {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	cancel()
+
+	if _, err := readGroup(ctx, client, readers, addr, time.Second, 1); err != context.Canceled {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestReadGroup_InboundNotConsumed(t *testing.T) {
+	client := newDummyGroupClient()
+	inbound := make(chan cemi.Message)
+	outbound := make(chan GroupEvent)
+	readers := newGroupReaders()
+
+	go serveGroupInbound(inbound, outbound, readers)
+
+	addr := cemi.NewGroupAddr3(1, 2, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Nobody reads the outbound channel, while more events arrive than can be kept.
+	for i := 0; i < groupInboundBacklog+10; i++ {
+		ldata := buildGroupOutbound(GroupEvent{Command: GroupWrite, Destination: addr, Data: []byte{1}})
+		inbound <- &cemi.LDataInd{LData: ldata}
+	}
+
+	go func() {
+		<-client.outbound
+
+		ldata := buildGroupOutbound(GroupEvent{Command: GroupResponse, Destination: addr, Data: []byte{7}})
+		inbound <- &cemi.LDataInd{LData: ldata}
+	}()
+
+	event, err := readGroup(ctx, client, readers, addr, time.Second, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(event.Data, []byte{7}) {
+		t.Errorf("Unexpected response: %+v", event)
+	}
+
+	close(inbound)
+
+	// The newest events are still delivered, followed by the end of the channel.
+	count := 0
+	for range outbound {
+		count++
+	}
+
+	if count != groupInboundBacklog {
+		t.Errorf("Unexpected number of inbound events %d", count)
+	}
+}
+
+func TestWriteValue(t *testing.T) {
+	client := newDummyGroupClient()
+	addr := cemi.NewGroupAddr3(1, 2, 3)
+	value := dpt.DPT_1001(true)
+
+	if err := writeValue(client, GroupResponse, addr, &value); err != nil {
+		t.Fatal(err)
+	}
+
+	if event := <-client.outbound; event.Command != GroupResponse || event.Destination != addr || event.Data[0] != 1 {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+}
@@ -6,6 +6,7 @@ package knxnet
 import (
 	"errors"
 
+	"github.com/vapourismo/knx-go/knx/cemi"
 	"github.com/vapourismo/knx-go/knx/util"
 )
 
@@ -80,6 +81,9 @@ type ConnRes struct {
 	Channel uint8
 	Status  ErrCode
 	Control HostInfo
+
+	// Address is the individual address which the gateway assigned to the tunnel connection.
+	Address cemi.IndividualAddr
 }
 
 // Service returns the service identifier for connection responses.
@@ -99,7 +103,8 @@ func (res *ConnRes) Size() uint {
 // Pack assembles the service payload in the given buffer.
 func (res *ConnRes) Pack(buffer []byte) {
 	if res.Status == 0 {
-		util.PackSome(buffer, res.Channel, uint8(0), &res.Control, []byte{4, 4, 0, 0})
+		util.PackSome(buffer, res.Channel, uint8(0), &res.Control,
+			[]byte{4, 4, byte(res.Address >> 8), byte(res.Address)})
 	} else {
 		util.PackSome(buffer, res.Channel, uint8(res.Status))
 	}
@@ -113,6 +118,13 @@ func (res *ConnRes) Unpack(data []byte) (n uint, err error) {
 		var m uint
 		m, err = res.Control.Unpack(data[2:])
 		n += m
+
+		// Only the connection response data block of tunnel connections (type 4) contains an
+		// address.
+		if crd := data[n:]; err == nil && len(crd) >= 4 && crd[0] >= 4 && crd[1] == 4 {
+			res.Address = cemi.IndividualAddr(crd[2])<<8 | cemi.IndividualAddr(crd[3])
+			n += 4
+		}
 	}
 
 	return
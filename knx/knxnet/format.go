@@ -0,0 +1,88 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knxnet
+
+import (
+	"fmt"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+// serviceNames contains the specification names of the known services.
+var serviceNames = map[ServiceID]string{
+	SearchReqService:    "SEARCH_REQUEST",
+	SearchResService:    "SEARCH_RESPONSE",
+	DescrReqService:     "DESCRIPTION_REQUEST",
+	DescrResService:     "DESCRIPTION_RESPONSE",
+	ConnReqService:      "CONNECT_REQUEST",
+	ConnResService:      "CONNECT_RESPONSE",
+	ConnStateReqService: "CONNECTIONSTATE_REQUEST",
+	ConnStateResService: "CONNECTIONSTATE_RESPONSE",
+	DiscReqService:      "DISCONNECT_REQUEST",
+	DiscResService:      "DISCONNECT_RESPONSE",
+	TunnelReqService:    "TUNNELING_REQUEST",
+	TunnelResService:    "TUNNELING_ACK",
+	RoutingIndService:   "ROUTING_INDICATION",
+	RoutingLostService:  "ROUTING_LOST_MESSAGE",
+	RoutingBusyService:  "ROUTING_BUSY",
+	RoutingSysBcService: "ROUTING_SYSTEM_BROADCAST",
+}
+
+// Name returns the name of the service as it is given in the specification.
+func (srv ServiceID) Name() string {
+	if name, ok := serviceNames[srv]; ok {
+		return name
+	}
+
+	return srv.String()
+}
+
+// Format generates a readable line for the service. Contained cEMI messages are formatted using
+// cemi.FormatMessage with the optional value formatter.
+func Format(srv Service, values cemi.ValueFormatter) string {
+	if srv == nil {
+		return "<nil>"
+	}
+
+	name := srv.Service().Name()
+
+	switch srv := srv.(type) {
+	case *TunnelReq:
+		return fmt.Sprintf("%s channel=%d seq=%d %s", name, srv.Channel, srv.SeqNumber,
+			cemi.FormatMessage(srv.Payload, values))
+
+	case *TunnelRes:
+		return fmt.Sprintf("%s channel=%d seq=%d status=%v", name, srv.Channel, srv.SeqNumber, srv.Status)
+
+	case *RoutingInd:
+		return name + " " + cemi.FormatMessage(srv.Payload, values)
+
+	case *RoutingSysBc:
+		return name + " " + cemi.FormatMessage(srv.Payload, values)
+
+	case *RoutingLost:
+		return fmt.Sprintf("%s state=%v count=%d", name, srv.Status, srv.Count)
+
+	case *RoutingBusy:
+		return fmt.Sprintf("%s state=%v wait=%v control=%#04x", name, srv.Status, srv.WaitTime, srv.Control)
+
+	case *ConnRes:
+		return fmt.Sprintf("%s channel=%d status=%v", name, srv.Channel, srv.Status)
+
+	case *ConnStateReq:
+		return fmt.Sprintf("%s channel=%d", name, srv.Channel)
+
+	case *ConnStateRes:
+		return fmt.Sprintf("%s channel=%d status=%v", name, srv.Channel, srv.Status)
+
+	case *DiscReq:
+		return fmt.Sprintf("%s channel=%d", name, srv.Channel)
+
+	case *DiscRes:
+		return fmt.Sprintf("%s channel=%d", name, srv.Channel)
+
+	default:
+		return name
+	}
+}
@@ -6,9 +6,10 @@ package knxnet
 
 import (
 	"errors"
-
 	"fmt"
+	"io"
 
+	"github.com/vapourismo/knx-go/knx/cemi"
 	"github.com/vapourismo/knx-go/knx/util"
 )
 
@@ -37,6 +38,7 @@ const (
 	RoutingIndService   ServiceID = 0x0530
 	RoutingLostService  ServiceID = 0x0531
 	RoutingBusyService  ServiceID = 0x0532
+	RoutingSysBcService ServiceID = 0x0533
 )
 
 // Service describes a KNXnet/IP service.
@@ -84,10 +86,15 @@ func Size(service ServicePackable) uint {
 
 // Pack generates a KNXnet/IP packet. Utilize Size() to determine the required size of the buffer.
 func Pack(buffer []byte, srv ServicePackable) {
+	serviceID := srv.Service()
+	totalLen := srv.Size() + 6
+
 	buffer[0] = 6
 	buffer[1] = 16
-	util.Pack(buffer[2:], uint16(srv.Service()))
-	util.Pack(buffer[4:], uint16(srv.Size()+6))
+	buffer[2] = byte(serviceID >> 8)
+	buffer[3] = byte(serviceID)
+	buffer[4] = byte(totalLen >> 8)
+	buffer[5] = byte(totalLen)
 	srv.Pack(buffer[6:])
 }
 
@@ -98,6 +105,15 @@ func AllocAndPack(srv ServicePackable) []byte {
 	return buffer
 }
 
+// AppendPack appends a KNXnet/IP packet to dst and returns the extended slice. It only allocates if
+// dst lacks the capacity.
+func AppendPack(dst []byte, srv ServicePackable) []byte {
+	offset := len(dst)
+	dst = util.Extend(dst, Size(srv))
+	Pack(dst[offset:], srv)
+	return dst
+}
+
 // These are errors that might occur during unpacking of the header.
 var (
 	ErrHeaderLength  = errors.New("header length is not 6")
@@ -111,22 +127,22 @@ type serviceUnpackable interface {
 
 // UnpackHeader extracts information from the KNXnet/IP packet header.
 func UnpackHeader(data []byte, serviceID *ServiceID, totalLen *uint16) (uint, error) {
-	var headerLen, version uint8
-
-	n, err := util.UnpackSome(data, &headerLen, &version, (*uint16)(serviceID), totalLen)
-	if err != nil {
-		return n, err
+	if len(data) < 6 {
+		return 0, io.ErrUnexpectedEOF
 	}
 
-	if headerLen != 6 {
-		return n, ErrHeaderLength
+	*serviceID = ServiceID(data[2])<<8 | ServiceID(data[3])
+	*totalLen = uint16(data[4])<<8 | uint16(data[5])
+
+	if data[0] != 6 {
+		return 6, ErrHeaderLength
 	}
 
-	if version != 16 {
-		return n, ErrHeaderVersion
+	if data[1] != 16 {
+		return 6, ErrHeaderVersion
 	}
 
-	return n, nil
+	return 6, nil
 }
 
 // Unpack parses a KNXnet/IP packet and retrieves its service payload.
@@ -206,6 +222,9 @@ func Unpack(data []byte, srv *Service) (uint, error) {
 	case RoutingBusyService:
 		body = &RoutingBusy{}
 
+	case RoutingSysBcService:
+		body = &RoutingSysBc{}
+
 	default:
 		body = &UnknownService{service: srvID}
 	}
@@ -218,3 +237,33 @@ func Unpack(data []byte, srv *Service) (uint, error) {
 
 	return n + m, err
 }
+
+// UnpackInto parses a KNXnet/IP packet like Unpack. If srv already points to a routing indication
+// or tunnel request and the packet contains the same service, that service and its payload are
+// overwritten instead of allocating new ones. Do not use it while the previous contents are still in
+// use elsewhere.
+func UnpackInto(data []byte, srv *Service) (uint, error) {
+	var srvID ServiceID
+	var totalLen uint16
+
+	n, err := UnpackHeader(data, &srvID, &totalLen)
+	if err != nil {
+		return n, err
+	}
+
+	switch body := (*srv).(type) {
+	case *RoutingInd:
+		if srvID == RoutingIndService && body != nil {
+			m, err := cemi.UnpackInto(data[n:], &body.Payload)
+			return n + m, err
+		}
+
+	case *TunnelReq:
+		if srvID == TunnelReqService && body != nil {
+			m, err := body.unpack(data[n:], true)
+			return n + m, err
+		}
+	}
+
+	return Unpack(data, srv)
+}
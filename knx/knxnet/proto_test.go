@@ -4,16 +4,16 @@
 package knxnet
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/vapourismo/knx-go/knx/cemi"
 	"github.com/vapourismo/knx-go/knx/util"
 )
 
-func BenchmarkPack(b *testing.B) {
-	b.ReportAllocs()
-
-	req := &TunnelReq{
+func makeTunnelReq() *TunnelReq {
+	return &TunnelReq{
 		Channel:   1,
 		SeqNumber: 0,
 		Payload: &cemi.LDataReq{
@@ -30,8 +30,132 @@ func BenchmarkPack(b *testing.B) {
 			},
 		},
 	}
+}
+
+func BenchmarkPack(b *testing.B) {
+	b.ReportAllocs()
+
+	req := makeTunnelReq()
 
 	for i := 0; i < b.N; i++ {
 		util.AllocAndPack(req)
 	}
 }
+
+func BenchmarkAppendPack(b *testing.B) {
+	b.ReportAllocs()
+
+	ind := &RoutingInd{Payload: &cemi.LDataInd{LData: makeTunnelReq().Payload.(*cemi.LDataReq).LData}}
+
+	for i := 0; i < b.N; i++ {
+		buffer := util.GetBuffer()
+		*buffer = AppendPack(*buffer, ind)
+		util.PutBuffer(buffer)
+	}
+}
+
+func BenchmarkUnpackInto(b *testing.B) {
+	b.ReportAllocs()
+
+	ind := &RoutingInd{Payload: &cemi.LDataInd{LData: makeTunnelReq().Payload.(*cemi.LDataReq).LData}}
+	data := AllocAndPack(ind)
+
+	var srv Service
+
+	for i := 0; i < b.N; i++ {
+		if _, err := UnpackInto(data, &srv); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestUnpackInto(t *testing.T) {
+	req := makeTunnelReq()
+	data := AllocAndPack(req)
+
+	var srv Service = &TunnelReq{Payload: &cemi.LDataReq{}}
+	previous := srv
+
+	for i := 0; i < 2; i++ {
+		if _, err := UnpackInto(data, &srv); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(srv, Service(req)) {
+			t.Errorf("Mismatch:\n%+v\n%+v", srv, req)
+		}
+
+		if srv != previous {
+			t.Error("Service has not been reused")
+		}
+	}
+
+	data[6] = 5
+	if _, err := UnpackInto(data, &srv); err == nil {
+		t.Error("Should not succeed with invalid header length")
+	}
+}
+
+func TestRoutingFlowControl(t *testing.T) {
+	services := []ServicePackable{
+		&RoutingLost{Status: DeviceStateOk, Count: 3},
+		&RoutingBusy{Status: DeviceStateOk, WaitTime: 100 * time.Millisecond, Control: 0},
+	}
+
+	for _, service := range services {
+		var srv Service
+		if _, err := Unpack(AllocAndPack(service), &srv); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(srv, service) {
+			t.Errorf("Unpacked %#v does not match %#v", srv, service)
+		}
+	}
+}
+
+func TestRoutingSysBc(t *testing.T) {
+	ldata := makeTunnelReq().Payload.(*cemi.LDataReq).LData
+	ldata.Control1.SetSysBroadcast(true)
+	ldata.Destination = 0
+
+	bc := &RoutingSysBc{Payload: &cemi.LDataInd{LData: ldata}}
+
+	data := AllocAndPack(bc)
+	if data[2] != 0x05 || data[3] != 0x33 {
+		t.Errorf("Unexpected service identifier %#x%02x", data[2], data[3])
+	}
+
+	var srv Service
+	if _, err := Unpack(data, &srv); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(srv, bc) {
+		t.Errorf("Unpacked %#v does not match %#v", srv, bc)
+	}
+}
+
+func TestConnRes(t *testing.T) {
+	res := &ConnRes{
+		Channel: 7,
+		Control: HostInfo{Protocol: UDP4, Address: Address{192, 168, 1, 10}, Port: 3671},
+		Address: cemi.NewIndividualAddr3(1, 1, 250),
+	}
+
+	data := AllocAndPack(res)
+	if crd := data[len(data)-4:]; !reflect.DeepEqual(crd, []byte{4, 4, 0x11, 0xFA}) {
+		t.Errorf("Unexpected connection response data block %v", crd)
+	}
+
+	var srv Service
+	if n, err := Unpack(data, &srv); err != nil {
+		t.Fatal(err)
+	} else if n != uint(len(data)) {
+		t.Errorf("Unexpected length %d, expected %d", n, len(data))
+	}
+
+	if !reflect.DeepEqual(srv, res) {
+		t.Errorf("Unpacked %#v does not match %#v", srv, res)
+	}
+}
@@ -5,6 +5,8 @@ package knxnet
 
 import (
 	"fmt"
+	"io"
+	"net"
 	"time"
 
 	"github.com/vapourismo/knx-go/knx/cemi"
@@ -14,6 +16,10 @@ import (
 // A RoutingInd indicates to one or more routers that the contents shall be routed.
 type RoutingInd struct {
 	Payload cemi.Message
+
+	// Interface is the network interface on which the indication has arrived. It is not part of
+	// the packet and only set by the receiving socket, if known.
+	Interface *net.Interface
 }
 
 // Service returns the service identifiers for routing indication.
@@ -36,6 +42,35 @@ func (ind *RoutingInd) Unpack(data []byte) (uint, error) {
 	return cemi.Unpack(data, &ind.Payload)
 }
 
+// A RoutingSysBc transports a system broadcast frame to one or more routers.
+type RoutingSysBc struct {
+	Payload cemi.Message
+
+	// Interface is the network interface on which the broadcast has arrived. It is not part of
+	// the packet and only set by the receiving socket, if known.
+	Interface *net.Interface
+}
+
+// Service returns the service identifiers for routing system broadcast.
+func (RoutingSysBc) Service() ServiceID {
+	return RoutingSysBcService
+}
+
+// Size returns the packed size.
+func (bc *RoutingSysBc) Size() uint {
+	return cemi.Size(bc.Payload)
+}
+
+// Pack assembles the service payload in the given buffer.
+func (bc *RoutingSysBc) Pack(buffer []byte) {
+	cemi.Pack(buffer, bc.Payload)
+}
+
+// Unpack parses the given service payload in order to initialize the structure.
+func (bc *RoutingSysBc) Unpack(data []byte) (uint, error) {
+	return cemi.Unpack(data, &bc.Payload)
+}
+
 // DeviceState indicates the state of a device.
 type DeviceState uint8
 
@@ -81,10 +116,28 @@ func (RoutingLost) Service() ServiceID {
 	return RoutingLostService
 }
 
+// Size returns the packed size.
+func (RoutingLost) Size() uint {
+	return 4
+}
+
+// Pack assembles the service payload in the given buffer.
+func (rl *RoutingLost) Pack(buffer []byte) {
+	buffer[0] = 4
+	buffer[1] = uint8(rl.Status)
+	util.PackUInt16(buffer[2:], rl.Count)
+}
+
 // Unpack parses the given service payload in order to initialize the structure.
 func (rl *RoutingLost) Unpack(data []byte) (uint, error) {
-	var length uint8
-	return util.UnpackSome(data, &length, (*uint8)(&rl.Status), &rl.Count)
+	if len(data) < 2 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	rl.Status = DeviceState(data[1])
+
+	n, err := util.UnpackUInt16(data[2:], &rl.Count)
+	return 2 + n, err
 
 	// TODO: Find out if length is supposed to be 4; validate it, if so.
 }
@@ -99,6 +152,10 @@ type RoutingBusy struct {
 
 	// If set to 0x00, must pause sending
 	Control uint16
+
+	// Sender is the address from which the indication has been received. It is not part of the
+	// packet and only set by the receiving socket.
+	Sender net.Addr
 }
 
 // Service returns the service identifiers for routing busy indication.
@@ -106,17 +163,32 @@ func (RoutingBusy) Service() ServiceID {
 	return RoutingBusyService
 }
 
+// Size returns the packed size.
+func (RoutingBusy) Size() uint {
+	return 6
+}
+
+// Pack assembles the service payload in the given buffer.
+func (rl *RoutingBusy) Pack(buffer []byte) {
+	buffer[0] = 6
+	buffer[1] = uint8(rl.Status)
+	util.PackUInt16(buffer[2:], uint16(rl.WaitTime/time.Millisecond))
+	util.PackUInt16(buffer[4:], rl.Control)
+}
+
 // Unpack parses the given service payload in order to initialize the structure.
 func (rl *RoutingBusy) Unpack(data []byte) (n uint, err error) {
-	var length uint8
-	var waitTime uint16
-
-	if n, err = util.UnpackSome(
-		data, &length, (*uint8)(&rl.Status), &waitTime, &rl.Control,
-	); err != nil {
-		return
+	if len(data) < 6 {
+		return 0, io.ErrUnexpectedEOF
 	}
 
+	rl.Status = DeviceState(data[1])
+
+	var waitTime uint16
+	util.UnpackUInt16(data[2:], &waitTime)
+	util.UnpackUInt16(data[4:], &rl.Control)
+	n = 6
+
 	// TODO: Find out if length is supposed to be 6; validate it, if so.
 
 	rl.WaitTime = time.Duration(waitTime) * time.Millisecond
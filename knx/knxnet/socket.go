@@ -28,9 +28,22 @@ type TunnelSocket struct {
 	inbound <-chan Service
 }
 
+// TunnelSocketConfig determines certain properties of a TunnelSocket.
+type TunnelSocketConfig struct {
+	// ReuseInbound makes the socket unpack incoming packets into previously delivered services,
+	// instead of allocating new ones. A service received from Inbound is then only valid until the
+	// next one is received. Do not use it if received services are retained, like knx.Tunnel does.
+	ReuseInbound bool
+}
+
 // DialTunnelUDP creates a new Socket which can used to exchange KNXnet/IP packets with a single
 // endpoint through UDP.
 func DialTunnelUDP(address string) (*TunnelSocket, error) {
+	return DialTunnelUDPWithConfig(address, TunnelSocketConfig{})
+}
+
+// DialTunnelUDPWithConfig creates a new Socket like DialTunnelUDP, using the given configuration.
+func DialTunnelUDPWithConfig(address string, config TunnelSocketConfig) (*TunnelSocket, error) {
 	addr, err := net.ResolveUDPAddr("udp4", address)
 	if err != nil {
 		return nil, err
@@ -48,7 +61,7 @@ func DialTunnelUDP(address string) (*TunnelSocket, error) {
 	conn.SetDeadline(time.Time{})
 
 	inbound := make(chan Service)
-	go serveUDPSocket(conn, addr, inbound)
+	go serveUDPSocket(conn, addr, newInboundSlots(config.ReuseInbound), inbound)
 
 	return &TunnelSocket{conn, inbound}, nil
 }
@@ -56,6 +69,11 @@ func DialTunnelUDP(address string) (*TunnelSocket, error) {
 // DialTunnelTCP creates a new Socket which can used to exchange KNXnet/IP packets with a single
 // endpoint through TCP.
 func DialTunnelTCP(address string) (*TunnelSocket, error) {
+	return DialTunnelTCPWithConfig(address, TunnelSocketConfig{})
+}
+
+// DialTunnelTCPWithConfig creates a new Socket like DialTunnelTCP, using the given configuration.
+func DialTunnelTCPWithConfig(address string, config TunnelSocketConfig) (*TunnelSocket, error) {
 	addr, err := net.ResolveTCPAddr("tcp4", address)
 	if err != nil {
 		return nil, err
@@ -73,18 +91,20 @@ func DialTunnelTCP(address string) (*TunnelSocket, error) {
 	conn.SetDeadline(time.Time{})
 
 	inbound := make(chan Service)
-	go serveTCPSocket(conn, addr, inbound)
+	go serveTCPSocket(conn, addr, newInboundSlots(config.ReuseInbound), inbound)
 
 	return &TunnelSocket{conn, inbound}, nil
 }
 
 // Send transmits a KNXnet/IP packet.
 func (sock *TunnelSocket) Send(payload ServicePackable) error {
-	buffer := make([]byte, Size(payload))
-	Pack(buffer, payload)
+	buffer := util.GetBuffer()
+	defer util.PutBuffer(buffer)
+
+	*buffer = AppendPack(*buffer, payload)
 
 	// Transmission of the buffer contents.
-	_, err := sock.conn.Write(buffer)
+	_, err := sock.conn.Write(*buffer)
 	return err
 }
 
@@ -105,9 +125,30 @@ func (sock *TunnelSocket) LocalAddr() net.Addr {
 
 // RouterSocket is a UDP socket for KNXnet/IP packet exchange.
 type RouterSocket struct {
-	conn    *net.UDPConn
-	addr    *net.UDPAddr
-	inbound <-chan Service
+	conn       *net.UDPConn
+	pc         *ipv4.PacketConn
+	addr       *net.UDPAddr
+	interfaces []*net.Interface
+	inbound    <-chan Service
+}
+
+// RouterSocketConfig determines certain properties of a RouterSocket.
+type RouterSocketConfig struct {
+	// Interfaces on which the multicast group is joined and packets are sent. If it is empty, the
+	// system-assigned multicast interface is used.
+	Interfaces []*net.Interface
+
+	// MulticastLoopback enables the reception of our own packets.
+	MulticastLoopback bool
+
+	// MulticastTTL is the time-to-live of outgoing packets. 0 keeps the system default of 1.
+	MulticastTTL int
+
+	// ReuseInbound makes the socket unpack incoming packets into previously delivered services,
+	// instead of allocating new ones. A service received from Inbound is then only valid until the
+	// next one is received. This suits monitors of busy networks. Do not use it if received
+	// services are retained, like knx.Router does.
+	ReuseInbound bool
 }
 
 // ListenRouter creates a new Socket which can be used to exchange KNXnet/IP packets with
@@ -120,6 +161,18 @@ func ListenRouter(multicastAddress string) (*RouterSocket, error) {
 // multiple endpoints. The interface is used to send or listen for KNXnet/IP packets. If the
 // interface is nil, the system-assigned multicast interface is used.
 func ListenRouterOnInterface(ifi *net.Interface, multicastAddress string, multicastLoopbackEnabled bool) (*RouterSocket, error) {
+	config := RouterSocketConfig{MulticastLoopback: multicastLoopbackEnabled}
+	if ifi != nil {
+		config.Interfaces = []*net.Interface{ifi}
+	}
+
+	return ListenRouterWithConfig(multicastAddress, config)
+}
+
+// ListenRouterWithConfig creates a new Socket which can be used to exchange KNXnet/IP packets with
+// multiple endpoints. Packets are sent on each of the configured interfaces. Inbound routing
+// indications and system broadcasts carry the interface on which they arrived.
+func ListenRouterWithConfig(multicastAddress string, config RouterSocketConfig) (*RouterSocket, error) {
 	addr, err := net.ResolveUDPAddr("udp4", multicastAddress)
 	if err != nil {
 		return nil, err
@@ -129,29 +182,67 @@ func ListenRouterOnInterface(ifi *net.Interface, multicastAddress string, multic
 	if err != nil {
 		return nil, err
 	}
-	pc := ipv4.NewPacketConn(conn)
 
-	if err := pc.JoinGroup(ifi, addr); err != nil {
+	sock, err := setupRouterSocket(conn, addr, config)
+	if err != nil {
+		conn.Close()
 		return nil, err
 	}
 
+	return sock, nil
+}
+
+// setupRouterSocket joins the multicast group and configures the outbound packets.
+func setupRouterSocket(conn *net.UDPConn, addr *net.UDPAddr, config RouterSocketConfig) (*RouterSocket, error) {
+	pc := ipv4.NewPacketConn(conn)
+
+	if len(config.Interfaces) == 0 {
+		if err := pc.JoinGroup(nil, addr); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, ifi := range config.Interfaces {
+		if err := pc.JoinGroup(ifi, addr); err != nil {
+			return nil, fmt.Errorf("failed to join group on interface %s: %w", ifi.Name, err)
+		}
+	}
+
+	// With multiple interfaces, the interface is chosen for each packet.
+	if len(config.Interfaces) == 1 {
+		if err := pc.SetMulticastInterface(config.Interfaces[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.MulticastTTL > 0 {
+		if err := pc.SetMulticastTTL(config.MulticastTTL); err != nil {
+			return nil, err
+		}
+	}
+
 	// Just for logging purposes.
 	if loopOn, err := pc.MulticastLoopback(); err == nil {
 		util.Log(conn, "MulticastLoopback status: %v", loopOn)
 	}
 	// Setup interface with Multicast Loopback enabled if desired.
-	if err := pc.SetMulticastLoopback(multicastLoopbackEnabled); err != nil {
+	if err := pc.SetMulticastLoopback(config.MulticastLoopback); err != nil {
 		util.Log(conn, "SetMulticastLoopback error: %v", err)
 	} else {
-		util.Log(conn, "MulticastLoopbackEnabled: %t", multicastLoopbackEnabled)
+		util.Log(conn, "MulticastLoopbackEnabled: %t", config.MulticastLoopback)
+	}
+
+	// The arrival interface is not available on all platforms.
+	if err := pc.SetControlMessage(ipv4.FlagInterface, true); err != nil {
+		util.Log(conn, "SetControlMessage error: %v", err)
 	}
 
 	conn.SetDeadline(time.Time{})
 
 	inbound := make(chan Service)
-	go serveUDPSocket(conn, nil, inbound)
+	go serveRouterSocket(pc, config.Interfaces, newInboundSlots(config.ReuseInbound), inbound)
 
-	return &RouterSocket{conn, addr, inbound}, nil
+	return &RouterSocket{conn, pc, addr, config.Interfaces, inbound}, nil
 }
 
 // Addr returns the multicast destination address.
@@ -161,14 +252,35 @@ func (sock *RouterSocket) Addr() *net.UDPAddr {
 
 // Send transmits a KNXnet/IP packet.
 func (sock *RouterSocket) Send(payload ServicePackable) error {
-	buffer := make([]byte, Size(payload))
-	Pack(buffer, payload)
+	buffer := util.GetBuffer()
+	defer util.PutBuffer(buffer)
+
+	*buffer = AppendPack(*buffer, payload)
+
+	if len(sock.interfaces) < 2 {
+		// Transmission of the buffer contents
+		_, err := sock.conn.WriteToUDP(*buffer, sock.addr)
+		return err
+	}
+
+	// Every interface is tried, the first error is reported.
+	var err error
+	for _, ifi := range sock.interfaces {
+		_, ifiErr := sock.pc.WriteTo(*buffer, &ipv4.ControlMessage{IfIndex: ifi.Index}, sock.addr)
+		if ifiErr != nil && err == nil {
+			err = fmt.Errorf("failed to send on interface %s: %w", ifi.Name, ifiErr)
+		}
+	}
 
-	// Transmission of the buffer contents
-	_, err := sock.conn.WriteToUDP(buffer, sock.addr)
 	return err
 }
 
+// Interfaces returns the interfaces on which packets are exchanged. It is empty if the
+// system-assigned multicast interface is used.
+func (sock *RouterSocket) Interfaces() []*net.Interface {
+	return sock.interfaces
+}
+
 // Inbound provides a channel from which you can retrieve incoming packets.
 func (sock *RouterSocket) Inbound() <-chan Service {
 	return sock.inbound
@@ -184,18 +296,57 @@ func (sock *RouterSocket) LocalAddr() net.Addr {
 	return sock.conn.LocalAddr()
 }
 
+// maxFrameSize is the maximum size of a KNXnet/IP frame.
+const maxFrameSize = 0xFFFF
+
+// inboundSlots provides the services into which the receiver workers unpack incoming packets.
+type inboundSlots struct {
+	reuse bool
+
+	// Inbound channels are unbuffered. Once a service has been received, the consumer is done with
+	// the one before it. Hence alternating between two services is sufficient.
+	slots [2]Service
+	next  int
+}
+
+// newInboundSlots creates the slots for a receiver worker. Without reuse, every packet is unpacked
+// into a new service.
+func newInboundSlots(reuse bool) *inboundSlots {
+	return &inboundSlots{reuse: reuse}
+}
+
+// unpack parses the packet into the next slot.
+func (slots *inboundSlots) unpack(data []byte) (Service, error) {
+	var srv Service
+	if slots.reuse {
+		srv = slots.slots[slots.next]
+	}
+
+	if _, err := UnpackInto(data, &srv); err != nil {
+		return nil, err
+	}
+
+	if slots.reuse {
+		slots.slots[slots.next] = srv
+		slots.next ^= 1
+	}
+
+	return srv, nil
+}
+
 // serveUDPSocket is the receiver worker for a UDP socket.
-func serveUDPSocket(conn *net.UDPConn, addr *net.UDPAddr, inbound chan<- Service) {
+func serveUDPSocket(conn *net.UDPConn, addr *net.UDPAddr, slots *inboundSlots, inbound chan<- Service) {
 	util.Log(conn, "Started worker")
 	defer util.Log(conn, "Worker exited")
 
 	// A closed inbound channel indicates to its readers that the worker has terminated.
 	defer close(inbound)
 
-	buffer := [1024]byte{}
+	// The total length in the KNXnet/IP header is a 16-bit field, so no valid frame exceeds it.
+	buffer := make([]byte, maxFrameSize)
 
 	for {
-		len, sender, err := conn.ReadFromUDP(buffer[:])
+		len, sender, err := conn.ReadFromUDP(buffer)
 		if err != nil {
 			util.Log(conn, "Error during ReadFromUDP: %v", err)
 			return
@@ -213,8 +364,7 @@ func serveUDPSocket(conn *net.UDPConn, addr *net.UDPAddr, inbound chan<- Service
 			continue
 		}
 
-		var payload Service
-		_, err = Unpack(buffer[:len], &payload)
+		payload, err := slots.unpack(buffer[:len])
 		if err != nil {
 			util.Log(conn, "Error during Unpack: %v", err)
 			continue
@@ -224,8 +374,86 @@ func serveUDPSocket(conn *net.UDPConn, addr *net.UDPAddr, inbound chan<- Service
 	}
 }
 
+// serveRouterSocket is the receiver worker for a multicast socket. It attaches the arrival interface
+// to routing indications and system broadcasts.
+func serveRouterSocket(pc *ipv4.PacketConn, interfaces []*net.Interface, slots *inboundSlots, inbound chan<- Service) {
+	util.Log(pc, "Started worker")
+	defer util.Log(pc, "Worker exited")
+
+	// A closed inbound channel indicates to its readers that the worker has terminated.
+	defer close(inbound)
+
+	buffer := make([]byte, maxFrameSize)
+
+	// Interfaces which have not been configured explicitly are looked up once.
+	known := make(map[int]*net.Interface)
+	for _, ifi := range interfaces {
+		known[ifi.Index] = ifi
+	}
+
+	// The message and its control buffer are reused for all packets. Unlike ReadFrom, ReadBatch
+	// does not allocate them for each packet.
+	msgs := []ipv4.Message{{
+		Buffers: [][]byte{buffer},
+		OOB:     ipv4.NewControlMessage(ipv4.FlagInterface),
+	}}
+
+	var cm ipv4.ControlMessage
+
+	for {
+		if _, err := pc.ReadBatch(msgs, 0); err != nil {
+			util.Log(pc, "Error during ReadBatch: %v", err)
+			return
+		}
+
+		len, sender := msgs[0].N, msgs[0].Addr
+
+		// The arrival interface is unknown if the control message is missing or malformed.
+		cm = ipv4.ControlMessage{}
+		if err := cm.Parse(msgs[0].OOB[:msgs[0].NN]); err != nil {
+			cm = ipv4.ControlMessage{}
+		}
+
+		// Discard empty frames
+		if len == 0 {
+			util.Log(pc, "Empty frame discarded")
+			continue
+		}
+
+		payload, err := slots.unpack(buffer[:len])
+		if err != nil {
+			util.Log(pc, "Error during Unpack: %v", err)
+			continue
+		}
+
+		var ifi *net.Interface
+		if cm.IfIndex > 0 {
+			var ok bool
+			if ifi, ok = known[cm.IfIndex]; !ok {
+				if ifi, err = net.InterfaceByIndex(cm.IfIndex); err == nil {
+					known[cm.IfIndex] = ifi
+				}
+			}
+		}
+
+		switch payload := payload.(type) {
+		case *RoutingInd:
+			payload.Interface = ifi
+
+		case *RoutingSysBc:
+			payload.Interface = ifi
+
+		case *RoutingBusy:
+			// Routers need to tell the senders of busy indications apart.
+			payload.Sender = sender
+		}
+
+		inbound <- payload
+	}
+}
+
 // serveTCPSocket is the receiver worker for a TCP socket.
-func serveTCPSocket(conn *net.TCPConn, addr *net.TCPAddr, inbound chan<- Service) {
+func serveTCPSocket(conn *net.TCPConn, addr *net.TCPAddr, slots *inboundSlots, inbound chan<- Service) {
 	util.Log(conn, "Started worker")
 	defer util.Log(conn, "Worker exited")
 
@@ -234,6 +462,9 @@ func serveTCPSocket(conn *net.TCPConn, addr *net.TCPAddr, inbound chan<- Service
 
 	connBuffer := bufio.NewReader(conn)
 
+	// The buffer is reused for all frames, because unpacking copies everything it retains.
+	var buffer []byte
+
 	for {
 		header, err := connBuffer.Peek(6) // KNXnet/IP headers are 6 bytes long
 		if err != nil {
@@ -250,7 +481,7 @@ func serveTCPSocket(conn *net.TCPConn, addr *net.TCPAddr, inbound chan<- Service
 			return
 		}
 
-		buffer := make([]byte, totalLen)
+		buffer = util.Extend(buffer[:0], uint(totalLen))
 		len, err := io.ReadFull(connBuffer, buffer)
 		if err != nil {
 			util.Log(conn, "Error during ReadFull: %v", err)
@@ -263,8 +494,7 @@ func serveTCPSocket(conn *net.TCPConn, addr *net.TCPAddr, inbound chan<- Service
 			continue
 		}
 
-		var payload Service
-		_, err = Unpack(buffer[:len], &payload)
+		payload, err := slots.unpack(buffer[:len])
 		if err != nil {
 			util.Log(conn, "Error during Unpack: %v", err)
 			continue
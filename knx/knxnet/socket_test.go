@@ -0,0 +1,217 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knxnet
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"golang.org/x/net/ipv4"
+)
+
+// listenLoopback creates a pair of connected UDP sockets on the loopback interface.
+func listenLoopback(t testing.TB) (*net.UDPConn, *net.UDPConn) {
+	local, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote, err := net.DialUDP("udp4", nil, local.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		local.Close()
+		t.Fatal(err)
+	}
+
+	return local, remote
+}
+
+// makeRoutingInd generates a routing indication that carries the given value.
+func makeRoutingInd(value byte) *RoutingInd {
+	return &RoutingInd{Payload: &cemi.LDataInd{LData: cemi.LData{
+		Control1:    cemi.Control1StdFrame | cemi.Control1NoRepeat | cemi.Control1NoSysBroadcast,
+		Control2:    cemi.Control2GroupAddr | cemi.Control2Hops(6),
+		Source:      0x1101,
+		Destination: 0x0801,
+		Data:        &cemi.AppData{Command: cemi.GroupValueWrite, Data: []byte{value}},
+	}}}
+}
+
+func TestServeRouterSocket_ReuseInbound(t *testing.T) {
+	for _, reuse := range []bool{false, true} {
+		local, remote := listenLoopback(t)
+
+		inbound := make(chan Service)
+		go serveRouterSocket(ipv4.NewPacketConn(local), nil, newInboundSlots(reuse), inbound)
+
+		var received []Service
+
+		for i := byte(0); i < 3; i++ {
+			ind := makeRoutingInd(i)
+			if _, err := remote.Write(AllocAndPack(ind)); err != nil {
+				t.Fatal(err)
+			}
+
+			srv := <-inbound
+
+			received = append(received, srv)
+			if !reflect.DeepEqual(srv.(*RoutingInd).Payload, ind.Payload) {
+				t.Errorf("Mismatch:\n%+v\n%+v", srv.(*RoutingInd).Payload, ind.Payload)
+			}
+		}
+
+		if reused := received[0] == received[2]; reused != reuse {
+			t.Errorf("Unexpected reuse %v of inbound services", reused)
+		}
+
+		if received[0] == received[1] {
+			t.Error("Consecutive services must not share memory")
+		}
+
+		remote.Close()
+		local.Close()
+
+		if _, open := <-inbound; open {
+			t.Error("Inbound channel should be closed")
+		}
+	}
+}
+
+func BenchmarkServeRouterSocket(b *testing.B) {
+	local, remote := listenLoopback(b)
+	defer remote.Close()
+	defer local.Close()
+
+	inbound := make(chan Service)
+	go serveRouterSocket(ipv4.NewPacketConn(local), nil, newInboundSlots(true), inbound)
+
+	packet := AllocAndPack(makeRoutingInd(1))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	// Packets are sent one at a time, so none are dropped by the receive buffer.
+	for i := 0; i < b.N; i++ {
+		if _, err := remote.Write(packet); err != nil {
+			b.Fatal(err)
+		}
+
+		<-inbound
+	}
+}
+
+// multicastInterface finds an interface on which multicast packets can be exchanged.
+func multicastInterface(t *testing.T) *net.Interface {
+	ifis, err := net.Interfaces()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	for i := range ifis {
+		if ifis[i].Flags&net.FlagUp != 0 && ifis[i].Flags&net.FlagMulticast != 0 {
+			return &ifis[i]
+		}
+	}
+
+	t.Skip("No multicast interface available")
+	return nil
+}
+
+// freeMulticastAddress picks a multicast address with an unused port.
+func freeMulticastAddress(t *testing.T) string {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	return (&net.UDPAddr{IP: net.IPv4(224, 0, 23, 12), Port: conn.LocalAddr().(*net.UDPAddr).Port}).String()
+}
+
+// receiveRoutingInd waits for a routing indication.
+func receiveRoutingInd(t *testing.T, inbound <-chan Service) *RoutingInd {
+	timeout := time.After(time.Second)
+
+	for {
+		select {
+		case srv := <-inbound:
+			if ind, ok := srv.(*RoutingInd); ok {
+				return ind
+			}
+
+		case <-timeout:
+			t.Fatal("Timed out waiting for routing indication")
+		}
+	}
+}
+
+func TestListenRouterWithConfig(t *testing.T) {
+	ifi := multicastInterface(t)
+
+	sock, err := ListenRouterWithConfig(freeMulticastAddress(t), RouterSocketConfig{
+		Interfaces:        []*net.Interface{ifi},
+		MulticastLoopback: true,
+		MulticastTTL:      3,
+	})
+	if err != nil {
+		t.Skip(err)
+	}
+	defer sock.Close()
+
+	if ttl, err := sock.pc.MulticastTTL(); err != nil || ttl != 3 {
+		t.Errorf("Unexpected multicast TTL %d (%v)", ttl, err)
+	}
+
+	// The outbound interface cannot be read back on all platforms. Receiving our own packet on it
+	// has to suffice.
+	ind := makeRoutingInd(1)
+	if err := sock.Send(ind); err != nil {
+		t.Fatal(err)
+	}
+
+	received := receiveRoutingInd(t, sock.Inbound())
+	if !reflect.DeepEqual(received.Payload, ind.Payload) {
+		t.Errorf("Mismatch:\n%+v\n%+v", received.Payload, ind.Payload)
+	}
+
+	if received.Interface != nil && received.Interface.Index != ifi.Index {
+		t.Errorf("Unexpected arrival interface %v", received.Interface)
+	}
+}
+
+func TestRouterSocket_SendInterfaces(t *testing.T) {
+	ifi := multicastInterface(t)
+
+	sock, err := ListenRouterWithConfig(freeMulticastAddress(t), RouterSocketConfig{
+		Interfaces:        []*net.Interface{ifi},
+		MulticastLoopback: true,
+	})
+	if err != nil {
+		t.Skip(err)
+	}
+	defer sock.Close()
+
+	// The group can only be joined once per interface. Sending is what matters here, therefore the
+	// interface is simply listed twice.
+	sock.interfaces = []*net.Interface{ifi, ifi}
+
+	ind := makeRoutingInd(2)
+	if err := sock.Send(ind); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		received := receiveRoutingInd(t, sock.Inbound())
+		if !reflect.DeepEqual(received.Payload, ind.Payload) {
+			t.Errorf("Mismatch:\n%+v\n%+v", received.Payload, ind.Payload)
+		}
+	}
+
+	sock.interfaces = []*net.Interface{ifi, {Index: 1 << 20, Name: "missing"}}
+	if err := sock.Send(ind); err == nil {
+		t.Error("Should not succeed with unknown interface")
+	}
+}
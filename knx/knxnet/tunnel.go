@@ -5,6 +5,7 @@ package knxnet
 
 import (
 	"errors"
+	"io"
 
 	"github.com/vapourismo/knx-go/knx/cemi"
 	"github.com/vapourismo/knx-go/knx/util"
@@ -43,19 +44,31 @@ func (req *TunnelReq) Pack(buffer []byte) {
 
 // Unpack parses the given service payload in order to initialize the structure.
 func (req *TunnelReq) Unpack(data []byte) (n uint, err error) {
-	var length, reserved uint8
+	return req.unpack(data, false)
+}
 
-	if n, err = util.UnpackSome(
-		data, &length, &req.Channel, &req.SeqNumber, &reserved,
-	); err != nil {
-		return
+// unpack parses the service payload. If reuse is true, the existing payload is overwritten if
+// possible.
+func (req *TunnelReq) unpack(data []byte, reuse bool) (n uint, err error) {
+	if len(data) < 4 {
+		return 0, io.ErrUnexpectedEOF
 	}
 
-	if length != 4 {
-		return n, errors.New("header length is not 4")
+	if data[0] != 4 {
+		return 4, errors.New("header length is not 4")
+	}
+
+	req.Channel = data[1]
+	req.SeqNumber = data[2]
+	n = 4
+
+	var m uint
+	if reuse {
+		m, err = cemi.UnpackInto(data[n:], &req.Payload)
+	} else {
+		m, err = cemi.Unpack(data[n:], &req.Payload)
 	}
 
-	m, err := cemi.Unpack(data[n:], &req.Payload)
 	n += m
 
 	return
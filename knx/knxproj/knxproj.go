@@ -0,0 +1,541 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+// Package knxproj reads group addresses, datapoint types and the topology from unencrypted ETS
+// project archives (.knxproj).
+package knxproj
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
+)
+
+// A GroupAddress is a group address of the project.
+type GroupAddress struct {
+	Address     cemi.GroupAddr
+	Name        string
+	Description string
+
+	// Datapoint type name as used by the dpt package (e.g. "9.001"), empty if none is assigned
+	DPT string
+}
+
+// Datapoint creates a datapoint for the assigned type.
+func (ga *GroupAddress) Datapoint() (dpt.Datapoint, bool) {
+	if ga.DPT == "" {
+		return nil, false
+	}
+
+	return dpt.Produce(ga.DPT)
+}
+
+// A GroupRange is a main or middle group of the group address hierarchy.
+type GroupRange struct {
+	Name        string
+	Description string
+	Start       cemi.GroupAddr
+	End         cemi.GroupAddr
+	Ranges      []GroupRange
+	Addresses   []GroupAddress
+}
+
+// A Device is a device instance in the topology.
+type Device struct {
+	// Individual address, 0 if the device has not been assigned an address yet
+	Address     cemi.IndividualAddr
+	Name        string
+	Description string
+	ProductName string
+	OrderNumber string
+}
+
+// A Line is a line of an area.
+type Line struct {
+	Address cemi.IndividualAddr
+	Name    string
+	Medium  string
+	Devices []Device
+}
+
+// An Area is a top-level element of the topology.
+type Area struct {
+	Address cemi.IndividualAddr
+	Name    string
+	Lines   []Line
+}
+
+// A Project is the content of an ETS project.
+type Project struct {
+	ID          string
+	Name        string
+	GroupRanges []GroupRange
+	Areas       []Area
+}
+
+// GroupAddresses returns all group addresses of the project in the order of the hierarchy.
+func (project *Project) GroupAddresses() []GroupAddress {
+	var addrs []GroupAddress
+
+	var walk func(ranges []GroupRange)
+	walk = func(ranges []GroupRange) {
+		for _, rng := range ranges {
+			addrs = append(addrs, rng.Addresses...)
+			walk(rng.Ranges)
+		}
+	}
+
+	walk(project.GroupRanges)
+
+	return addrs
+}
+
+// DPTs returns the datapoint types of all group addresses that have one assigned. The result can
+// be used with knx.NewGroupDecoder and dpt.NewValueFormatter.
+func (project *Project) DPTs() map[cemi.GroupAddr]string {
+	types := make(map[cemi.GroupAddr]string)
+
+	for _, addr := range project.GroupAddresses() {
+		if addr.DPT != "" {
+			types[addr.Address] = addr.DPT
+		}
+	}
+
+	return types
+}
+
+// Devices returns all devices of the topology.
+func (project *Project) Devices() []Device {
+	var devices []Device
+
+	for _, area := range project.Areas {
+		for _, line := range area.Lines {
+			devices = append(devices, line.Devices...)
+		}
+	}
+
+	return devices
+}
+
+// ParseDatapointType converts an ETS datapoint type reference like "DPST-9-1" into the name used
+// by the dpt package ("9.001"). If the reference contains multiple types, the first convertible
+// sub-type is used. References to main types only ("DPT-9") are mapped to the lowest sub-type of
+// that main type which the dpt package supports. All sub-types of a main type share the encoding,
+// but the unit of the chosen sub-type may not apply.
+func ParseDatapointType(ref string) (string, bool) {
+	fields := strings.Fields(ref)
+
+	for _, field := range fields {
+		parts := strings.Split(field, "-")
+		if len(parts) != 3 || parts[0] != "DPST" {
+			continue
+		}
+
+		main, err := strconv.ParseUint(parts[1], 10, 16)
+		if err != nil {
+			continue
+		}
+
+		sub, err := strconv.ParseUint(parts[2], 10, 16)
+		if err != nil {
+			continue
+		}
+
+		return fmt.Sprintf("%d.%03d", main, sub), true
+	}
+
+	for _, field := range fields {
+		parts := strings.Split(field, "-")
+		if len(parts) != 2 || parts[0] != "DPT" {
+			continue
+		}
+
+		main, err := strconv.ParseUint(parts[1], 10, 16)
+		if err != nil {
+			continue
+		}
+
+		if name, ok := lowestSubType(main); ok {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// lowestSubType finds the lowest supported sub-type of the given main type.
+func lowestSubType(main uint64) (string, bool) {
+	prefix := fmt.Sprintf("%d.", main)
+
+	var lowest string
+	for _, name := range dpt.ListSupportedTypes() {
+		// Sub-types have three digits, therefore they can be compared as strings.
+		if strings.HasPrefix(name, prefix) && (lowest == "" || name < lowest) {
+			lowest = name
+		}
+	}
+
+	return lowest, lowest != ""
+}
+
+// These are the XML elements that are extracted from the project files. The namespace differs
+// between ETS versions, therefore it is ignored.
+type (
+	xmlGroupAddress struct {
+		Address       uint16 `xml:"Address,attr"`
+		Name          string `xml:"Name,attr"`
+		Description   string `xml:"Description,attr"`
+		DatapointType string `xml:"DatapointType,attr"`
+	}
+
+	xmlGroupRange struct {
+		Name        string            `xml:"Name,attr"`
+		Description string            `xml:"Description,attr"`
+		RangeStart  uint16            `xml:"RangeStart,attr"`
+		RangeEnd    uint16            `xml:"RangeEnd,attr"`
+		Ranges      []xmlGroupRange   `xml:"GroupRange"`
+		Addresses   []xmlGroupAddress `xml:"GroupAddress"`
+	}
+
+	xmlDevice struct {
+		Address      *uint8 `xml:"Address,attr"`
+		Name         string `xml:"Name,attr"`
+		Description  string `xml:"Description,attr"`
+		ProductRefID string `xml:"ProductRefId,attr"`
+	}
+
+	xmlLine struct {
+		Address      uint8       `xml:"Address,attr"`
+		Name         string      `xml:"Name,attr"`
+		MediumTypeID string      `xml:"MediumTypeRefId,attr"`
+		Devices      []xmlDevice `xml:"DeviceInstance"`
+		Segments     []struct {
+			MediumTypeID string      `xml:"MediumTypeRefId,attr"`
+			Devices      []xmlDevice `xml:"DeviceInstance"`
+		} `xml:"Segment"`
+	}
+
+	xmlArea struct {
+		Address uint8     `xml:"Address,attr"`
+		Name    string    `xml:"Name,attr"`
+		Lines   []xmlLine `xml:"Line"`
+	}
+
+	xmlProjectData struct {
+		Project struct {
+			ID            string `xml:"Id,attr"`
+			Installations []struct {
+				Areas  []xmlArea       `xml:"Topology>Area"`
+				Ranges []xmlGroupRange `xml:"GroupAddresses>GroupRanges>GroupRange"`
+			} `xml:"Installations>Installation"`
+		} `xml:"Project"`
+	}
+
+	xmlProjectInfo struct {
+		Project struct {
+			Information struct {
+				Name string `xml:"Name,attr"`
+			} `xml:"ProjectInformation"`
+		} `xml:"Project"`
+	}
+
+	xmlProduct struct {
+		ID          string `xml:"Id,attr"`
+		Text        string `xml:"Text,attr"`
+		OrderNumber string `xml:"OrderNumber,attr"`
+	}
+
+	xmlHardwareData struct {
+		Products []xmlProduct `xml:"ManufacturerData>Manufacturer>Hardware>Hardware>Products>Product"`
+	}
+)
+
+// mediumNames maps the ETS medium type identifiers to readable names.
+var mediumNames = map[string]string{
+	"MT-0": "TP",
+	"MT-1": "PL",
+	"MT-2": "RF",
+	"MT-5": "IP",
+}
+
+var (
+	errNoProject        = errors.New("archive does not contain a project")
+	errProtectedProject = errors.New("password-protected projects are not supported")
+)
+
+// archive provides access to the files of a project archive.
+type archive struct {
+	files map[string]*zip.File
+}
+
+// newArchive indexes the files of a zip archive.
+func newArchive(reader *zip.Reader) *archive {
+	arch := &archive{files: make(map[string]*zip.File, len(reader.File))}
+
+	for _, file := range reader.File {
+		arch.files[file.Name] = file
+	}
+
+	return arch
+}
+
+// decode parses the given XML file.
+func (arch *archive) decode(name string, value interface{}) error {
+	file, ok := arch.files[name]
+	if !ok {
+		return fmt.Errorf("archive does not contain %s", name)
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	return xml.NewDecoder(reader).Decode(value)
+}
+
+// Open reads the project archive at the given path.
+func Open(name string) (*Project, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return Read(file, info.Size())
+}
+
+// Read reads a project archive.
+func Read(reader io.ReaderAt, size int64) (*Project, error) {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, err
+	}
+
+	arch := newArchive(zipReader)
+
+	// The archive is searched in a fixed order, so that the same project is chosen every time.
+	names := make([]string, 0, len(arch.files))
+	for name := range arch.files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	// Locate the project directory, e.g. "P-0123/0.xml".
+	for _, name := range names {
+		dir, base := path.Split(name)
+		if strings.HasPrefix(dir, "P-") && base == "0.xml" {
+			return readProject(arch, dir)
+		}
+	}
+
+	// Some exports nest the project in another archive, which is encrypted if the project is
+	// password-protected. The project directory is preferred if an archive contains both.
+	for _, name := range names {
+		dir, base := path.Split(name)
+		if dir == "" && strings.HasPrefix(base, "P-") && path.Ext(base) == ".zip" {
+			return readNestedProject(arch, arch.files[name])
+		}
+	}
+
+	return nil, errNoProject
+}
+
+// readNestedProject reads the project from an archive inside the project archive.
+func readNestedProject(outer *archive, file *zip.File) (*Project, error) {
+	// Bit 0 of the general purpose flags indicates encryption.
+	if file.Flags&1 != 0 {
+		return nil, errProtectedProject
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	inner := newArchive(zipReader)
+	for _, file := range inner.files {
+		if file.Flags&1 != 0 {
+			return nil, errProtectedProject
+		}
+	}
+
+	if _, ok := inner.files["0.xml"]; !ok {
+		return nil, errNoProject
+	}
+
+	// Product data stays in the outer archive.
+	for name, file := range outer.files {
+		if _, ok := inner.files[name]; !ok {
+			inner.files[name] = file
+		}
+	}
+
+	return readProject(inner, "")
+}
+
+// readProject reads the project in the given directory.
+func readProject(arch *archive, dir string) (*Project, error) {
+	var data xmlProjectData
+	if err := arch.decode(dir+"0.xml", &data); err != nil {
+		return nil, err
+	}
+
+	project := &Project{ID: data.Project.ID}
+
+	var info xmlProjectInfo
+	if err := arch.decode(dir+"project.xml", &info); err == nil {
+		project.Name = info.Project.Information.Name
+	}
+
+	products := readProducts(arch)
+
+	for _, inst := range data.Project.Installations {
+		for _, rng := range inst.Ranges {
+			project.GroupRanges = append(project.GroupRanges, convertGroupRange(rng))
+		}
+
+		for _, area := range inst.Areas {
+			project.Areas = append(project.Areas, convertArea(area, products))
+		}
+	}
+
+	return project, nil
+}
+
+// readProducts collects the products from the hardware descriptions of all manufacturers.
+func readProducts(arch *archive) map[string]xmlProduct {
+	products := make(map[string]xmlProduct)
+
+	for name := range arch.files {
+		dir, base := path.Split(name)
+		if !strings.HasPrefix(dir, "M-") || base != "Hardware.xml" {
+			continue
+		}
+
+		var data xmlHardwareData
+		if err := arch.decode(name, &data); err != nil {
+			continue
+		}
+
+		for _, product := range data.Products {
+			products[product.ID] = product
+		}
+	}
+
+	return products
+}
+
+// convertGroupRange converts a group range and its children.
+func convertGroupRange(rng xmlGroupRange) GroupRange {
+	result := GroupRange{
+		Name:        rng.Name,
+		Description: rng.Description,
+		Start:       cemi.GroupAddr(rng.RangeStart),
+		End:         cemi.GroupAddr(rng.RangeEnd),
+	}
+
+	for _, child := range rng.Ranges {
+		result.Ranges = append(result.Ranges, convertGroupRange(child))
+	}
+
+	for _, addr := range rng.Addresses {
+		name, _ := ParseDatapointType(addr.DatapointType)
+
+		result.Addresses = append(result.Addresses, GroupAddress{
+			Address:     cemi.GroupAddr(addr.Address),
+			Name:        addr.Name,
+			Description: addr.Description,
+			DPT:         name,
+		})
+	}
+
+	return result
+}
+
+// convertArea converts an area of the topology.
+func convertArea(area xmlArea, products map[string]xmlProduct) Area {
+	result := Area{
+		Address: cemi.NewIndividualAddr3(area.Address, 0, 0),
+		Name:    area.Name,
+	}
+
+	for _, line := range area.Lines {
+		medium := line.MediumTypeID
+		devices := line.Devices
+
+		// ETS 6 places devices and the medium into segments.
+		for _, segment := range line.Segments {
+			if medium == "" {
+				medium = segment.MediumTypeID
+			}
+
+			devices = append(devices, segment.Devices...)
+		}
+
+		if name, ok := mediumNames[medium]; ok {
+			medium = name
+		}
+
+		lineResult := Line{
+			Address: cemi.NewIndividualAddr3(area.Address, line.Address, 0),
+			Name:    line.Name,
+			Medium:  medium,
+		}
+
+		for _, device := range devices {
+			converted := Device{
+				Name:        device.Name,
+				Description: device.Description,
+			}
+
+			if device.Address != nil {
+				converted.Address = cemi.NewIndividualAddr3(area.Address, line.Address, *device.Address)
+			}
+
+			if product, ok := products[device.ProductRefID]; ok {
+				converted.ProductName = product.Text
+				converted.OrderNumber = product.OrderNumber
+			}
+
+			lineResult.Devices = append(lineResult.Devices, converted)
+		}
+
+		result.Lines = append(result.Lines, lineResult)
+	}
+
+	return result
+}
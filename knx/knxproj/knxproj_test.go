@@ -0,0 +1,219 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knxproj
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+const testProjectData = `<?xml version="1.0" encoding="utf-8"?>
+<KNX xmlns="http://knx.org/xml/project/21">
+  <Project Id="P-0123">
+    <Installations>
+      <Installation Name="">
+        <Topology>
+          <Area Id="P-0123-0_A-1" Name="Building" Address="1">
+            <Line Id="P-0123-0_L-1" Name="Ground floor" Address="1">
+              <Segment Id="P-0123-0_S-1" MediumTypeRefId="MT-0">
+                <DeviceInstance Id="P-0123-0_DI-1" Name="Actuator" ProductRefId="M-0083_H-1-P-1" Address="5" />
+                <DeviceInstance Id="P-0123-0_DI-2" Name="Unassigned" ProductRefId="M-0083_H-1-P-2" />
+              </Segment>
+            </Line>
+          </Area>
+        </Topology>
+        <GroupAddresses>
+          <GroupRanges>
+            <GroupRange Name="Lights" RangeStart="2048" RangeEnd="4095">
+              <GroupRange Name="Kitchen" RangeStart="2048" RangeEnd="2303">
+                <GroupAddress Id="P-0123-0_GA-1" Address="2049" Name="Switch" Description="Ceiling" DatapointType="DPST-1-1" />
+                <GroupAddress Id="P-0123-0_GA-2" Address="2050" Name="Temperature" DatapointType="DPT-9 DPST-9-1" />
+                <GroupAddress Id="P-0123-0_GA-3" Address="2051" Name="Untyped" />
+              </GroupRange>
+            </GroupRange>
+          </GroupRanges>
+        </GroupAddresses>
+      </Installation>
+    </Installations>
+  </Project>
+</KNX>`
+
+const testProjectInfo = `<?xml version="1.0" encoding="utf-8"?>
+<KNX xmlns="http://knx.org/xml/project/21">
+  <Project Id="P-0123">
+    <ProjectInformation Name="Home" />
+  </Project>
+</KNX>`
+
+const testHardware = `<?xml version="1.0" encoding="utf-8"?>
+<KNX xmlns="http://knx.org/xml/project/21">
+  <ManufacturerData>
+    <Manufacturer RefId="M-0083">
+      <Hardware>
+        <Hardware Id="M-0083_H-1" Name="Actuator">
+          <Products>
+            <Product Id="M-0083_H-1-P-1" Text="Switch actuator 4-fold" OrderNumber="SA-4" />
+          </Products>
+        </Hardware>
+      </Hardware>
+    </Manufacturer>
+  </ManufacturerData>
+</KNX>`
+
+func makeArchive(t *testing.T, files map[string]string) *bytes.Reader {
+	buffer := &bytes.Buffer{}
+	writer := zip.NewWriter(buffer)
+
+	for name, content := range files {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		file.Write([]byte(content))
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.NewReader(buffer.Bytes())
+}
+
+func TestParseDatapointType(t *testing.T) {
+	cases := map[string]string{
+		"DPST-1-1":        "1.001",
+		"DPST-9-1":        "9.001",
+		"DPST-232-600":    "232.600",
+		"DPT-5 DPST-5-10": "5.010",
+		"DPT-1":           "1.001",
+		"DPT-9 DPST-9-1":  "9.001",
+		"DPST-9-1 DPT-5":  "9.001",
+		"DPT-5":           "5.001",
+		"DPT-65535":       "",
+		"DPST-1":          "",
+		"":                "",
+	}
+
+	for ref, expected := range cases {
+		name, ok := ParseDatapointType(ref)
+		if name != expected || ok != (expected != "") {
+			t.Errorf("Unexpected result for %q: %q %v", ref, name, ok)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	reader := makeArchive(t, map[string]string{
+		"knx_master.xml":        `<KNX />`,
+		"P-0123/0.xml":          testProjectData,
+		"P-0123/project.xml":    testProjectInfo,
+		"M-0083/Hardware.xml":   testHardware,
+		"M-0083/M-0083_A-1.xml": `<KNX />`,
+	})
+
+	project, err := Read(reader, reader.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if project.ID != "P-0123" || project.Name != "Home" {
+		t.Errorf("Unexpected project information: %q %q", project.ID, project.Name)
+	}
+
+	if len(project.GroupRanges) != 1 || len(project.GroupRanges[0].Ranges) != 1 {
+		t.Fatalf("Unexpected group ranges: %+v", project.GroupRanges)
+	}
+
+	middle := project.GroupRanges[0].Ranges[0]
+	if middle.Name != "Kitchen" || middle.Start != cemi.NewGroupAddr3(1, 0, 0) || middle.End != cemi.NewGroupAddr3(1, 0, 255) {
+		t.Errorf("Unexpected middle group: %+v", middle)
+	}
+
+	expectedTypes := map[cemi.GroupAddr]string{
+		cemi.NewGroupAddr3(1, 0, 1): "1.001",
+		cemi.NewGroupAddr3(1, 0, 2): "9.001",
+	}
+
+	if types := project.DPTs(); !reflect.DeepEqual(types, expectedTypes) {
+		t.Errorf("Unexpected datapoint types: %v", types)
+	}
+
+	addrs := project.GroupAddresses()
+	if len(addrs) != 3 || addrs[0].Name != "Switch" || addrs[0].Description != "Ceiling" {
+		t.Errorf("Unexpected group addresses: %+v", addrs)
+	}
+
+	if _, ok := addrs[0].Datapoint(); !ok {
+		t.Error("Datapoint should be available")
+	}
+
+	expectedDevices := []Device{
+		{
+			Address:     cemi.NewIndividualAddr3(1, 1, 5),
+			Name:        "Actuator",
+			ProductName: "Switch actuator 4-fold",
+			OrderNumber: "SA-4",
+		},
+		{Name: "Unassigned"},
+	}
+
+	if devices := project.Devices(); !reflect.DeepEqual(devices, expectedDevices) {
+		t.Errorf("Unexpected devices: %+v", devices)
+	}
+
+	if line := project.Areas[0].Lines[0]; line.Medium != "TP" || line.Address != cemi.NewIndividualAddr3(1, 1, 0) {
+		t.Errorf("Unexpected line: %+v", line)
+	}
+}
+
+func TestRead_Nested(t *testing.T) {
+	nested := makeArchive(t, map[string]string{
+		"0.xml": strings.Replace(testProjectData, "P-0123", "P-0456", 1),
+	})
+
+	data := make([]byte, nested.Size())
+	nested.Read(data)
+
+	reader := makeArchive(t, map[string]string{"P-0456.zip": string(data)})
+
+	project, err := Read(reader, reader.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if project.ID != "P-0456" {
+		t.Errorf("Unexpected nested project %q", project.ID)
+	}
+
+	// The project directory takes precedence over the nested archive.
+	for i := 0; i < 10; i++ {
+		reader := makeArchive(t, map[string]string{
+			"P-0123/0.xml": testProjectData,
+			"P-0456.zip":   string(data),
+		})
+
+		project, err := Read(reader, reader.Size())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if project.ID != "P-0123" {
+			t.Fatalf("Unexpected project %q", project.ID)
+		}
+	}
+}
+
+func TestRead_NoProject(t *testing.T) {
+	reader := makeArchive(t, map[string]string{"knx_master.xml": `<KNX />`})
+
+	if _, err := Read(reader, reader.Size()); err == nil {
+		t.Error("Should not succeed without project")
+	}
+}
@@ -0,0 +1,800 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/util"
+)
+
+// A messageClient exchanges CEMI-encoded messages. Tunnel and Router implement this.
+type messageClient interface {
+	Send(data cemi.Message) error
+	Inbound() <-chan cemi.Message
+}
+
+// ManagementConfig allows you to configure the behaviour of the management client.
+type ManagementConfig struct {
+	// AckTimeout specifies how long to wait for a transport layer acknowledgement.
+	AckTimeout time.Duration
+
+	// ResponseTimeout specifies how long to wait for a response from the device.
+	ResponseTimeout time.Duration
+
+	// MaxRepetitions specifies how often an unacknowledged request is repeated. Requests are always
+	// repeated at least once.
+	MaxRepetitions uint
+}
+
+// DefaultManagementConfig is a good default configuration for a management client.
+var DefaultManagementConfig = ManagementConfig{
+	AckTimeout:      3 * time.Second,
+	ResponseTimeout: 6 * time.Second,
+	MaxRepetitions:  3,
+}
+
+// checkManagementConfig makes sure that the configuration is actually usable.
+func checkManagementConfig(config ManagementConfig) ManagementConfig {
+	if config.AckTimeout <= 0 {
+		config.AckTimeout = DefaultManagementConfig.AckTimeout
+	}
+
+	if config.ResponseTimeout <= 0 {
+		config.ResponseTimeout = DefaultManagementConfig.ResponseTimeout
+	}
+
+	if config.MaxRepetitions == 0 {
+		config.MaxRepetitions = DefaultManagementConfig.MaxRepetitions
+	}
+
+	return config
+}
+
+// These are the transport layer control commands used by the connection-oriented mode.
+const (
+	transportConnect    uint8 = 0
+	transportDisconnect uint8 = 1
+	transportAck        uint8 = 2
+	transportNak        uint8 = 3
+)
+
+var (
+	errDeviceDisconnected = errors.New("device connection has been closed")
+	errAckTimeout         = errors.New("device did not acknowledge the request")
+)
+
+// A Management provides device management services, like ETS does, on top of a Tunnel.
+type Management struct {
+	client messageClient
+	config ManagementConfig
+
+	mu        sync.Mutex
+	conns     map[cemi.IndividualAddr]*DeviceConn
+	listeners map[chan BroadcastEvent]struct{}
+}
+
+// NewManagement creates a management client for the given tunnel. The tunnel should not be used
+// for anything else, because the management client consumes its inbound channel. You may pass a
+// zero-initialized value as parameter config, the default values will be set up.
+func NewManagement(tunnel *Tunnel, config ManagementConfig) *Management {
+	return newManagement(tunnel, config)
+}
+
+func newManagement(client messageClient, config ManagementConfig) *Management {
+	mgmt := &Management{
+		client:    client,
+		config:    checkManagementConfig(config),
+		conns:     map[cemi.IndividualAddr]*DeviceConn{},
+		listeners: map[chan BroadcastEvent]struct{}{},
+	}
+
+	go mgmt.serve()
+
+	return mgmt
+}
+
+// send transmits the transport unit to the given device.
+func (mgmt *Management) send(dest cemi.IndividualAddr, unit cemi.TransportUnit) error {
+	ldata := cemi.LData{
+		Control1: cemi.Control1NoRepeat | cemi.Control1NoSysBroadcast | cemi.Control1WantAck |
+			cemi.Control1Prio(cemi.PrioLow),
+		Control2:    cemi.Control2Hops(6),
+		Destination: uint16(dest),
+		Data:        unit,
+	}
+
+	ldata.SetFrameType()
+
+	return mgmt.client.Send(&cemi.LDataReq{LData: ldata})
+}
+
+// serve dispatches incoming frames to the device connections.
+func (mgmt *Management) serve() {
+	util.Log(mgmt, "Started worker")
+	defer util.Log(mgmt, "Worker exited")
+
+	for msg := range mgmt.client.Inbound() {
+		ind, ok := msg.(*cemi.LDataInd)
+		if !ok {
+			continue
+		}
+
+		if ind.Control2.IsGroupAddr() {
+			if ind.Destination == 0 {
+				mgmt.dispatchBroadcast(&ind.LData)
+			}
+
+			continue
+		}
+
+		mgmt.mu.Lock()
+		conn := mgmt.conns[ind.Source]
+		mgmt.mu.Unlock()
+
+		if conn != nil {
+			conn.handle(ind.Data)
+		}
+	}
+
+	mgmt.mu.Lock()
+	defer mgmt.mu.Unlock()
+
+	for _, conn := range mgmt.conns {
+		conn.terminate()
+	}
+
+	for listener := range mgmt.listeners {
+		close(listener)
+		delete(mgmt.listeners, listener)
+	}
+}
+
+// dispatchBroadcast hands a broadcast frame to all listeners.
+func (mgmt *Management) dispatchBroadcast(ldata *cemi.LData) {
+	app, ok := ldata.Data.(*cemi.AppData)
+	if !ok {
+		return
+	}
+
+	event := BroadcastEvent{
+		System: ldata.Control1.SysBroadcast(),
+		Source: ldata.Source,
+		App:    app,
+	}
+
+	mgmt.mu.Lock()
+	defer mgmt.mu.Unlock()
+
+	for listener := range mgmt.listeners {
+		select {
+		case listener <- event:
+		default:
+			util.Log(mgmt, "Broadcast listener is full, dropping frame")
+		}
+	}
+}
+
+// Connect establishes a transport layer connection to the device with the given address.
+func (mgmt *Management) Connect(addr cemi.IndividualAddr) (*DeviceConn, error) {
+	mgmt.mu.Lock()
+	defer mgmt.mu.Unlock()
+
+	if _, ok := mgmt.conns[addr]; ok {
+		return nil, fmt.Errorf("a connection to %v already exists", addr)
+	}
+
+	conn := &DeviceConn{
+		mgmt:    mgmt,
+		addr:    addr,
+		inbound: make(chan cemi.TransportUnit, 16),
+		done:    make(chan struct{}),
+	}
+
+	if err := mgmt.send(addr, &cemi.ControlData{Command: transportConnect}); err != nil {
+		return nil, err
+	}
+
+	mgmt.conns[addr] = conn
+
+	return conn, nil
+}
+
+// A DeviceConn is a connection-oriented transport layer connection to a single device.
+type DeviceConn struct {
+	mgmt *Management
+	addr cemi.IndividualAddr
+
+	// Outgoing requests are serialized.
+	mu      sync.Mutex
+	seqSend uint8
+
+	// Only touched by the dispatching worker.
+	seqRecv uint8
+
+	inbound chan cemi.TransportUnit
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Address returns the individual address of the device.
+func (conn *DeviceConn) Address() cemi.IndividualAddr {
+	return conn.addr
+}
+
+// terminate marks the connection as closed.
+func (conn *DeviceConn) terminate() {
+	conn.once.Do(func() {
+		close(conn.done)
+	})
+}
+
+// release removes the connection from the management client.
+func (conn *DeviceConn) release() {
+	conn.terminate()
+
+	conn.mgmt.mu.Lock()
+	defer conn.mgmt.mu.Unlock()
+
+	if conn.mgmt.conns[conn.addr] == conn {
+		delete(conn.mgmt.conns, conn.addr)
+	}
+}
+
+// handle processes a transport unit that has been received from the device. Numbered data is
+// acknowledged here, so that the device does not need to repeat it.
+func (conn *DeviceConn) handle(unit cemi.TransportUnit) {
+	switch unit := unit.(type) {
+	case *cemi.ControlData:
+		if unit.Command == transportDisconnect && !unit.Numbered {
+			util.Log(conn, "Device %v closed the connection", conn.addr)
+			conn.release()
+			return
+		}
+
+	case *cemi.AppData:
+		if unit.Numbered {
+			switch unit.SeqNumber {
+			case conn.seqRecv:
+				conn.mgmt.send(conn.addr, &cemi.ControlData{
+					Numbered: true, SeqNumber: unit.SeqNumber, Command: transportAck,
+				})
+				conn.seqRecv = (conn.seqRecv + 1) & 15
+
+			case (conn.seqRecv - 1) & 15:
+				// This is a repetition of something we have already seen.
+				conn.mgmt.send(conn.addr, &cemi.ControlData{
+					Numbered: true, SeqNumber: unit.SeqNumber, Command: transportAck,
+				})
+				return
+
+			default:
+				conn.mgmt.send(conn.addr, &cemi.ControlData{
+					Numbered: true, SeqNumber: unit.SeqNumber, Command: transportNak,
+				})
+				return
+			}
+		}
+	}
+
+	select {
+	case conn.inbound <- unit:
+	default:
+		util.Log(conn, "Inbound queue is full, dropping %T", unit)
+	}
+}
+
+// request sends the application data to the device and waits for its acknowledgement. If match
+// is not nil, it also waits for a response for which match returns true.
+func (conn *DeviceConn) request(app *cemi.AppData, match func(*cemi.AppData) bool) (*cemi.AppData, error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	select {
+	case <-conn.done:
+		return nil, errDeviceDisconnected
+	default:
+	}
+
+	app.Numbered = true
+	app.SeqNumber = conn.seqSend
+
+	var response *cemi.AppData
+	acked := false
+
+	for repetition := uint(0); !acked; repetition++ {
+		if repetition > conn.mgmt.config.MaxRepetitions {
+			conn.Close()
+			return nil, errAckTimeout
+		}
+
+		if err := conn.mgmt.send(conn.addr, app); err != nil {
+			return nil, err
+		}
+
+		timeout := time.NewTimer(conn.mgmt.config.AckTimeout)
+
+	waitAck:
+		for {
+			select {
+			case <-conn.done:
+				timeout.Stop()
+				return nil, errDeviceDisconnected
+
+			case <-timeout.C:
+				break waitAck
+
+			case unit := <-conn.inbound:
+				switch unit := unit.(type) {
+				case *cemi.ControlData:
+					if !unit.Numbered || unit.SeqNumber != conn.seqSend {
+						continue
+					}
+
+					if unit.Command == transportAck {
+						acked = true
+						timeout.Stop()
+						break waitAck
+					}
+
+					if unit.Command == transportNak {
+						timeout.Stop()
+						break waitAck
+					}
+
+				case *cemi.AppData:
+					if match != nil && response == nil && match(unit) {
+						response = unit
+					}
+				}
+			}
+		}
+	}
+
+	conn.seqSend = (conn.seqSend + 1) & 15
+
+	if match == nil || response != nil {
+		return response, nil
+	}
+
+	timeout := time.NewTimer(conn.mgmt.config.ResponseTimeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-conn.done:
+			return nil, errDeviceDisconnected
+
+		case <-timeout.C:
+			return nil, errResponseTimeout
+
+		case unit := <-conn.inbound:
+			if app, ok := unit.(*cemi.AppData); ok && match(app) {
+				return app, nil
+			}
+		}
+	}
+}
+
+// notify sends the application data to the device without waiting for its acknowledgement.
+func (conn *DeviceConn) notify(app *cemi.AppData) error {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	select {
+	case <-conn.done:
+		return errDeviceDisconnected
+	default:
+	}
+
+	app.Numbered = true
+	app.SeqNumber = conn.seqSend
+
+	if err := conn.mgmt.send(conn.addr, app); err != nil {
+		return err
+	}
+
+	conn.seqSend = (conn.seqSend + 1) & 15
+
+	return nil
+}
+
+// matchCommand creates a function that matches application data with the given extended APCI.
+func matchCommand(apci cemi.ExtendedAPCI) func(*cemi.AppData) bool {
+	return func(app *cemi.AppData) bool {
+		return app.ExtendedCommand() == apci
+	}
+}
+
+// Close disconnects from the device.
+func (conn *DeviceConn) Close() error {
+	select {
+	case <-conn.done:
+		return nil
+	default:
+	}
+
+	conn.release()
+
+	return conn.mgmt.send(conn.addr, &cemi.ControlData{Command: transportDisconnect})
+}
+
+// DeviceDescriptor reads the device descriptor of the given type. Descriptor type 0 returns the
+// mask version.
+func (conn *DeviceConn) DeviceDescriptor(descriptorType uint8) ([]byte, error) {
+	res, err := conn.request(
+		&cemi.AppData{Command: cemi.MaskVersionRead, Data: []byte{descriptorType & 63}},
+		func(app *cemi.AppData) bool {
+			// Descriptor type 63 indicates that the requested type is not supported.
+			return app.Command == cemi.MaskVersionResponse && len(app.Data) > 0 &&
+				(app.Data[0]&63 == descriptorType&63 || app.Data[0]&63 == 63)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Data[0]&63 != descriptorType&63 {
+		return nil, errors.New("device does not support the requested descriptor type")
+	}
+
+	return res.Data[1:], nil
+}
+
+// MaskVersion reads the mask version (device descriptor type 0) of the device.
+func (conn *DeviceConn) MaskVersion() (uint16, error) {
+	data, err := conn.DeviceDescriptor(0)
+	if err != nil {
+		return 0, err
+	}
+
+	var mask uint16
+	if _, err := util.Unpack(data, &mask); err != nil {
+		return 0, err
+	}
+
+	return mask, nil
+}
+
+// ReadMemory reads count bytes starting at the given memory address. At most 63 bytes can be read
+// at once; a standard frame only fits 12 bytes.
+func (conn *DeviceConn) ReadMemory(address uint16, count uint8) ([]byte, error) {
+	if count == 0 || count > 63 {
+		return nil, fmt.Errorf("invalid memory read count %d", count)
+	}
+
+	res, err := conn.request(
+		&cemi.AppData{
+			Command: cemi.MemoryRead,
+			Data:    []byte{count, byte(address >> 8), byte(address)},
+		},
+		func(app *cemi.AppData) bool {
+			return app.Command == cemi.MemoryResponse && len(app.Data) >= 3 &&
+				uint16(app.Data[1])<<8|uint16(app.Data[2]) == address
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	length := res.Data[0] & 63
+	if length == 0 {
+		return nil, fmt.Errorf("device denied reading memory at %#04x", address)
+	}
+
+	if int(length) > len(res.Data)-3 {
+		return nil, fmt.Errorf("memory response is truncated")
+	}
+
+	return res.Data[3 : 3+length], nil
+}
+
+// WriteMemory writes the data to the memory starting at the given address. At most 63 bytes can
+// be written at once; a standard frame only fits 12 bytes.
+func (conn *DeviceConn) WriteMemory(address uint16, data []byte) error {
+	if len(data) == 0 || len(data) > 63 {
+		return fmt.Errorf("invalid memory write length %d", len(data))
+	}
+
+	payload := append([]byte{byte(len(data)), byte(address >> 8), byte(address)}, data...)
+
+	_, err := conn.request(&cemi.AppData{Command: cemi.MemoryWrite, Data: payload}, nil)
+	return err
+}
+
+// MemoryExtendedError is a return code of an extended memory service.
+type MemoryExtendedError uint8
+
+// Error returns a description of the return code.
+func (code MemoryExtendedError) Error() string {
+	switch code {
+	case 0xF1:
+		return "memory access denied"
+
+	case 0xF2:
+		return "memory address space exceeded"
+
+	case 0xF3:
+		return "memory access length exceeded"
+
+	case 0xF5:
+		return "memory area is read-only"
+
+	default:
+		return fmt.Sprintf("memory access failed with return code %#02x", uint8(code))
+	}
+}
+
+// maxMemoryExtendedCount is the maximum number of bytes that an extended memory service transfers.
+const maxMemoryExtendedCount = 250
+
+// ReadMemoryExtended reads count bytes starting at the given 24-bit memory address. At most 250
+// bytes can be read at once.
+func (conn *DeviceConn) ReadMemoryExtended(address uint32, count uint8) ([]byte, error) {
+	if count == 0 || count > maxMemoryExtendedCount {
+		return nil, fmt.Errorf("invalid memory read count %d", count)
+	}
+
+	addr := []byte{byte(address >> 16), byte(address >> 8), byte(address)}
+
+	res, err := conn.request(
+		cemi.NewExtendedAppData(cemi.MemoryExtendedRead, append([]byte{count}, addr...)),
+		func(app *cemi.AppData) bool {
+			return app.ExtendedCommand() == cemi.MemoryExtendedReadResponse && len(app.Data) >= 5 &&
+				app.Data[2] == addr[0] && app.Data[3] == addr[1] && app.Data[4] == addr[2]
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Data[1] != 0 {
+		return nil, MemoryExtendedError(res.Data[1])
+	}
+
+	if int(count) > len(res.Data)-5 {
+		return nil, fmt.Errorf("memory response is truncated")
+	}
+
+	return res.Data[5 : 5+int(count)], nil
+}
+
+// WriteMemoryExtended writes the data to the memory starting at the given 24-bit address. At most
+// 250 bytes can be written at once.
+func (conn *DeviceConn) WriteMemoryExtended(address uint32, data []byte) error {
+	if len(data) == 0 || len(data) > maxMemoryExtendedCount {
+		return fmt.Errorf("invalid memory write length %d", len(data))
+	}
+
+	addr := []byte{byte(address >> 16), byte(address >> 8), byte(address)}
+	payload := append(append([]byte{byte(len(data))}, addr...), data...)
+
+	res, err := conn.request(
+		cemi.NewExtendedAppData(cemi.MemoryExtendedWrite, payload),
+		func(app *cemi.AppData) bool {
+			return app.ExtendedCommand() == cemi.MemoryExtendedWriteResponse && len(app.Data) >= 5 &&
+				app.Data[2] == addr[0] && app.Data[3] == addr[1] && app.Data[4] == addr[2]
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if res.Data[1] != 0 {
+		return MemoryExtendedError(res.Data[1])
+	}
+
+	return nil
+}
+
+// propertyHeader encodes the header shared by the property value services.
+func propertyHeader(objectIndex, propertyID uint8, count uint8, start uint16) []byte {
+	return []byte{objectIndex, propertyID, count<<4 | byte(start>>8)&15, byte(start)}
+}
+
+// ReadProperty reads count elements of an interface object property, starting at the given
+// element index. Element index 0 returns the current number of elements.
+func (conn *DeviceConn) ReadProperty(objectIndex, propertyID uint8, count uint8, start uint16) ([]byte, error) {
+	if count == 0 || count > 15 || start > 0xFFF {
+		return nil, fmt.Errorf("invalid property element range %d+%d", start, count)
+	}
+
+	header := propertyHeader(objectIndex, propertyID, count, start)
+
+	res, err := conn.request(
+		cemi.NewExtendedAppData(cemi.PropertyValueRead, header),
+		matchPropertyResponse(objectIndex, propertyID, start),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Data[3]>>4 == 0 {
+		return nil, fmt.Errorf("device denied reading property %d of object %d", propertyID, objectIndex)
+	}
+
+	return res.Data[5:], nil
+}
+
+// WriteProperty writes count elements of an interface object property, starting at the given
+// element index. The data must contain all elements.
+func (conn *DeviceConn) WriteProperty(objectIndex, propertyID uint8, count uint8, start uint16, data []byte) error {
+	if count == 0 || count > 15 || start > 0xFFF {
+		return fmt.Errorf("invalid property element range %d+%d", start, count)
+	}
+
+	header := propertyHeader(objectIndex, propertyID, count, start)
+
+	res, err := conn.request(
+		cemi.NewExtendedAppData(cemi.PropertyValueWrite, append(header, data...)),
+		matchPropertyResponse(objectIndex, propertyID, start),
+	)
+	if err != nil {
+		return err
+	}
+
+	if res.Data[3]>>4 == 0 {
+		return fmt.Errorf("device denied writing property %d of object %d", propertyID, objectIndex)
+	}
+
+	return nil
+}
+
+// matchPropertyResponse matches a property value response for the given property.
+func matchPropertyResponse(objectIndex, propertyID uint8, start uint16) func(*cemi.AppData) bool {
+	return func(app *cemi.AppData) bool {
+		return app.ExtendedCommand() == cemi.PropertyValueResponse && len(app.Data) >= 5 &&
+			app.Data[1] == objectIndex && app.Data[2] == propertyID &&
+			uint16(app.Data[3]&15)<<8|uint16(app.Data[4]) == start
+	}
+}
+
+// PropertyDescription describes an interface object property.
+type PropertyDescription struct {
+	ObjectIndex   uint8
+	PropertyID    uint8
+	PropertyIndex uint8
+	WriteEnabled  bool
+	Type          uint8
+	MaxElements   uint16
+	ReadLevel     uint8
+	WriteLevel    uint8
+}
+
+// ReadPropertyDescription reads the description of an interface object property. If propertyID is
+// 0, the property is looked up by its index instead.
+func (conn *DeviceConn) ReadPropertyDescription(objectIndex, propertyID, propertyIndex uint8) (PropertyDescription, error) {
+	res, err := conn.request(
+		cemi.NewExtendedAppData(
+			cemi.PropertyDescriptionRead,
+			[]byte{objectIndex, propertyID, propertyIndex},
+		),
+		func(app *cemi.AppData) bool {
+			return app.ExtendedCommand() == cemi.PropertyDescriptionResponse && len(app.Data) >= 4 &&
+				app.Data[1] == objectIndex &&
+				(propertyID == 0 && app.Data[3] == propertyIndex || app.Data[2] == propertyID)
+		},
+	)
+	if err != nil {
+		return PropertyDescription{}, err
+	}
+
+	if len(res.Data) < 8 {
+		return PropertyDescription{}, fmt.Errorf("property description response is truncated")
+	}
+
+	desc := PropertyDescription{
+		ObjectIndex:   res.Data[1],
+		PropertyID:    res.Data[2],
+		PropertyIndex: res.Data[3],
+		WriteEnabled:  res.Data[4]&0x80 != 0,
+		Type:          res.Data[4] & 63,
+		MaxElements:   uint16(res.Data[5]&15)<<8 | uint16(res.Data[6]),
+		ReadLevel:     res.Data[7] >> 4,
+		WriteLevel:    res.Data[7] & 15,
+	}
+
+	if desc.Type == 0 && desc.MaxElements == 0 {
+		return desc, fmt.Errorf("property %d of object %d does not exist", propertyID, objectIndex)
+	}
+
+	return desc, nil
+}
+
+// ProgrammingMode reads whether the device is in programming mode.
+func (conn *DeviceConn) ProgrammingMode() (bool, error) {
+	data, err := conn.ReadMemory(0x0060, 1)
+	if err != nil {
+		return false, err
+	}
+
+	return data[0]&1 == 1, nil
+}
+
+// MaxAPDULength reads the maximum APDU length that the device supports from its device object.
+// Devices which lack the property only support standard frames, i.e. cemi.MaxStandardAPDULength,
+// and respond with an error.
+func (conn *DeviceConn) MaxAPDULength() (uint, error) {
+	data, err := conn.ReadProperty(0, 56, 1, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	var length uint16
+	if _, err := util.Unpack(data, &length); err != nil {
+		return 0, err
+	}
+
+	return uint(length), nil
+}
+
+// Restart performs a basic restart of the device. The device terminates the connection. Devices
+// often restart before they acknowledge the request, therefore Restart does not wait for it.
+func (conn *DeviceConn) Restart() error {
+	err := conn.notify(&cemi.AppData{Command: cemi.Restart, Data: []byte{0}})
+	conn.release()
+	return err
+}
+
+// EraseCode determines what a master reset erases.
+type EraseCode uint8
+
+// These are known erase codes.
+const (
+	EraseConfirmedRestart      EraseCode = 1
+	EraseFactoryReset          EraseCode = 2
+	EraseResetIA               EraseCode = 3
+	EraseResetAP               EraseCode = 4
+	EraseResetParam            EraseCode = 5
+	EraseResetLinks            EraseCode = 6
+	EraseFactoryResetWithoutIA EraseCode = 7
+)
+
+// RestartError is an error code in a master reset response.
+type RestartError uint8
+
+// Error returns a description of the error code.
+func (code RestartError) Error() string {
+	switch code {
+	case 1:
+		return "restart access denied"
+
+	case 2:
+		return "unsupported erase code"
+
+	case 3:
+		return "invalid channel number"
+
+	default:
+		return fmt.Sprintf("restart failed with error code %#02x", uint8(code))
+	}
+}
+
+// MasterReset resets the device using the given erase code and channel. It returns the time the
+// device needs to process the reset. The device terminates the connection.
+func (conn *DeviceConn) MasterReset(erase EraseCode, channel uint8) (time.Duration, error) {
+	res, err := conn.request(
+		cemi.NewExtendedAppData(cemi.RestartMasterReset, []byte{uint8(erase), channel}),
+		matchCommand(cemi.RestartResponse),
+	)
+	conn.release()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(res.Data) < 4 {
+		return 0, fmt.Errorf("restart response is truncated")
+	}
+
+	if res.Data[1] != 0 {
+		return 0, RestartError(res.Data[1])
+	}
+
+	return time.Duration(uint16(res.Data[2])<<8|uint16(res.Data[3])) * time.Second, nil
+}
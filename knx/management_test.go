@@ -0,0 +1,426 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+// dummyClient is a messageClient that hands outgoing messages to a test.
+type dummyClient struct {
+	outbound chan cemi.Message
+	inbound  chan cemi.Message
+}
+
+func newDummyClient() *dummyClient {
+	return &dummyClient{
+		outbound: make(chan cemi.Message, 100),
+		inbound:  make(chan cemi.Message, 100),
+	}
+}
+
+func (client *dummyClient) Send(data cemi.Message) error {
+	client.outbound <- data
+	return nil
+}
+
+func (client *dummyClient) Inbound() <-chan cemi.Message {
+	return client.inbound
+}
+
+// dummyDevice answers management requests like a device would.
+type dummyDevice struct {
+	client   *dummyClient
+	addr     cemi.IndividualAddr
+	seqSend  uint8
+	serial   cemi.SerialNumber
+	progMode bool
+}
+
+// next retrieves the next outgoing transport unit.
+func (dev *dummyDevice) next() (cemi.TransportUnit, error) {
+	select {
+	case msg := <-dev.client.outbound:
+		req, ok := msg.(*cemi.LDataReq)
+		if !ok {
+			return nil, fmt.Errorf("unexpected message type %T", msg)
+		}
+
+		if req.Destination != uint16(dev.addr) || req.Control2.IsGroupAddr() {
+			return nil, fmt.Errorf("unexpected destination %v", req.Destination)
+		}
+
+		return req.Data, nil
+
+	case <-time.After(time.Second):
+		return nil, errors.New("timeout while waiting for an outgoing message")
+	}
+}
+
+// send transmits a transport unit from the device.
+func (dev *dummyDevice) send(unit cemi.TransportUnit) {
+	dev.client.inbound <- &cemi.LDataInd{
+		LData: cemi.LData{
+			Control2: cemi.Control2Hops(6),
+			Source:   dev.addr,
+			Data:     unit,
+		},
+	}
+}
+
+// broadcast transmits a transport unit from the device to all devices.
+func (dev *dummyDevice) broadcast(unit cemi.TransportUnit) {
+	dev.client.inbound <- &cemi.LDataInd{
+		LData: cemi.LData{
+			Control2: cemi.Control2GroupAddr | cemi.Control2Hops(6),
+			Source:   dev.addr,
+			Data:     unit,
+		},
+	}
+}
+
+// simulate handles all outgoing messages until the client is closed. It supports individual
+// address programming and acknowledges requests addressed to the device.
+func (dev *dummyDevice) simulate() {
+	for msg := range dev.client.outbound {
+		req := msg.(*cemi.LDataReq)
+
+		if req.Control2.IsGroupAddr() {
+			if req.Destination != 0 {
+				continue
+			}
+
+			app := req.Data.(*cemi.AppData)
+
+			switch {
+			case app.Command == cemi.IndividualAddrRequest && dev.progMode:
+				dev.broadcast(&cemi.AppData{Command: cemi.IndividualAddrResponse})
+
+			case app.Command == cemi.IndividualAddrWrite && dev.progMode:
+				dev.addr = cemi.IndividualAddr(app.Data[1])<<8 | cemi.IndividualAddr(app.Data[2])
+
+			case app.ExtendedCommand() == cemi.IndividualAddrSerialNumberRead &&
+				string(app.Data[1:7]) == string(dev.serial[:]):
+				dev.broadcast(cemi.NewExtendedAppData(
+					cemi.IndividualAddrSerialNumberResponse,
+					append(dev.serial[:], 0, 0, 0, 0),
+				))
+
+			case app.ExtendedCommand() == cemi.IndividualAddrSerialNumberWrite &&
+				string(app.Data[1:7]) == string(dev.serial[:]):
+				dev.addr = cemi.IndividualAddr(app.Data[7])<<8 | cemi.IndividualAddr(app.Data[8])
+			}
+
+			continue
+		}
+
+		if req.Destination != uint16(dev.addr) {
+			continue
+		}
+
+		if app, ok := req.Data.(*cemi.AppData); ok && app.Numbered {
+			dev.send(&cemi.ControlData{
+				Numbered: true, SeqNumber: app.SeqNumber, Command: transportAck,
+			})
+
+			if app.Command == cemi.Restart {
+				dev.progMode = false
+			}
+		}
+	}
+}
+
+// answer acknowledges the next request and responds with the given application data.
+func (dev *dummyDevice) answer(response *cemi.AppData) (*cemi.AppData, error) {
+	unit, err := dev.next()
+	if err != nil {
+		return nil, err
+	}
+
+	req, ok := unit.(*cemi.AppData)
+	if !ok || !req.Numbered {
+		return nil, fmt.Errorf("unexpected request %+v", unit)
+	}
+
+	dev.send(&cemi.ControlData{Numbered: true, SeqNumber: req.SeqNumber, Command: transportAck})
+
+	if response != nil {
+		response.Numbered = true
+		response.SeqNumber = dev.seqSend
+		dev.send(response)
+
+		unit, err := dev.next()
+		if err != nil {
+			return nil, err
+		}
+
+		ack, ok := unit.(*cemi.ControlData)
+		if !ok || ack.Command != transportAck || ack.SeqNumber != dev.seqSend {
+			return nil, fmt.Errorf("unexpected acknowledgement %+v", unit)
+		}
+
+		dev.seqSend++
+	}
+
+	return req, nil
+}
+
+// serve answers the next request in the background. The returned channel yields the request.
+func (dev *dummyDevice) serve(t *testing.T, response *cemi.AppData) <-chan *cemi.AppData {
+	done := make(chan *cemi.AppData, 1)
+
+	go func() {
+		defer close(done)
+
+		req, err := dev.answer(response)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		done <- req
+	}()
+
+	return done
+}
+
+// wait answers the next request in the background. The returned function waits for the answer.
+func (dev *dummyDevice) wait(t *testing.T, response *cemi.AppData) func() {
+	done := dev.serve(t, response)
+	return func() { <-done }
+}
+
+func TestDeviceConn(t *testing.T) {
+	client := newDummyClient()
+	defer close(client.inbound)
+
+	addr := cemi.NewIndividualAddr3(1, 1, 5)
+	dev := &dummyDevice{client: client, addr: addr}
+
+	mgmt := newManagement(client, ManagementConfig{AckTimeout: time.Second, ResponseTimeout: time.Second})
+
+	conn, err := mgmt.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if unit, err := dev.next(); err != nil {
+		t.Fatal(err)
+	} else if ctrl, ok := unit.(*cemi.ControlData); !ok || ctrl.Command != transportConnect {
+		t.Fatalf("Expected T_Connect, got %+v", unit)
+	}
+
+	t.Run("MaskVersion", func(t *testing.T) {
+		defer dev.wait(t, &cemi.AppData{Command: cemi.MaskVersionResponse, Data: []byte{0, 0x07, 0xB0}})()
+
+		mask, err := conn.MaskVersion()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mask != 0x07B0 {
+			t.Errorf("Unexpected mask version: %#04x", mask)
+		}
+	})
+
+	t.Run("ReadMemory", func(t *testing.T) {
+		defer dev.wait(t, &cemi.AppData{Command: cemi.MemoryResponse, Data: []byte{2, 0x01, 0x04, 0xAB, 0xCD}})()
+
+		data, err := conn.ReadMemory(0x0104, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(data, []byte{0xAB, 0xCD}) {
+			t.Errorf("Unexpected memory contents: %v", data)
+		}
+	})
+
+	t.Run("ReadMemoryExtended", func(t *testing.T) {
+		defer dev.wait(t, cemi.NewExtendedAppData(
+			cemi.MemoryExtendedReadResponse, []byte{0, 0x01, 0x00, 0x10, 0xAB, 0xCD},
+		))()
+
+		data, err := conn.ReadMemoryExtended(0x010010, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(data, []byte{0xAB, 0xCD}) {
+			t.Errorf("Unexpected memory contents: %v", data)
+		}
+	})
+
+	t.Run("ReadMemoryExtendedTruncated", func(t *testing.T) {
+		defer dev.wait(t, cemi.NewExtendedAppData(
+			cemi.MemoryExtendedReadResponse, []byte{0, 0x01, 0x00, 0x10, 0xAB},
+		))()
+
+		if _, err := conn.ReadMemoryExtended(0x010010, 2); err == nil {
+			t.Fatal("Should not succeed with truncated response")
+		}
+	})
+
+	t.Run("ReadMemoryExtendedCount", func(t *testing.T) {
+		if _, err := conn.ReadMemoryExtended(0, 251); err == nil {
+			t.Fatal("Should not succeed with excessive count")
+		}
+	})
+
+	t.Run("ProgrammingMode", func(t *testing.T) {
+		defer dev.wait(t, &cemi.AppData{Command: cemi.MemoryResponse, Data: []byte{1, 0x00, 0x60, 0x81}})()
+
+		progMode, err := conn.ProgrammingMode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !progMode {
+			t.Error("Expected programming mode to be active")
+		}
+	})
+
+	t.Run("WriteProperty", func(t *testing.T) {
+		done := dev.serve(t, cemi.NewExtendedAppData(
+			cemi.PropertyValueResponse, []byte{0, 14, 0x10, 0x01, 0x12, 0x34},
+		))
+
+		err := conn.WriteProperty(0, 14, 1, 1, []byte{0x12, 0x34})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := <-done
+		if req == nil {
+			t.FailNow()
+		}
+
+		if req.ExtendedCommand() != cemi.PropertyValueWrite {
+			t.Errorf("Unexpected command: %#x", req.ExtendedCommand())
+		}
+
+		if !bytes.Equal(req.Data[1:], []byte{0, 14, 0x10, 0x01, 0x12, 0x34}) {
+			t.Errorf("Unexpected request data: %v", req.Data)
+		}
+	})
+
+	t.Run("ReadPropertyDenied", func(t *testing.T) {
+		defer dev.wait(t, cemi.NewExtendedAppData(cemi.PropertyValueResponse, []byte{0, 14, 0x00, 0x01}))()
+
+		if _, err := conn.ReadProperty(0, 14, 1, 1); err == nil {
+			t.Fatal("Should not succeed")
+		}
+	})
+
+	t.Run("ReadPropertyDescription", func(t *testing.T) {
+		defer dev.wait(t, cemi.NewExtendedAppData(
+			cemi.PropertyDescriptionResponse, []byte{0, 14, 3, 0x84, 0x00, 0x01, 0x32},
+		))()
+
+		desc, err := conn.ReadPropertyDescription(0, 14, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := PropertyDescription{
+			ObjectIndex:   0,
+			PropertyID:    14,
+			PropertyIndex: 3,
+			WriteEnabled:  true,
+			Type:          4,
+			MaxElements:   1,
+			ReadLevel:     3,
+			WriteLevel:    2,
+		}
+
+		if desc != expected {
+			t.Errorf("Unexpected description: %+v", desc)
+		}
+	})
+
+	t.Run("MasterReset", func(t *testing.T) {
+		defer dev.wait(t, cemi.NewExtendedAppData(cemi.RestartResponse, []byte{0, 0, 5}))()
+
+		processTime, err := conn.MasterReset(EraseFactoryReset, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if processTime != 5*time.Second {
+			t.Errorf("Unexpected process time: %v", processTime)
+		}
+
+		if _, err := conn.ReadMemory(0, 1); err != errDeviceDisconnected {
+			t.Errorf("Expected error %v, got %v", errDeviceDisconnected, err)
+		}
+	})
+}
+
+func TestDeviceConn_Restart(t *testing.T) {
+	client := newDummyClient()
+	defer close(client.inbound)
+
+	addr := cemi.NewIndividualAddr3(1, 1, 5)
+	dev := &dummyDevice{client: client, addr: addr}
+
+	// The device would restart without acknowledging. A timeout would exceed the test duration.
+	mgmt := newManagement(client, ManagementConfig{AckTimeout: time.Minute})
+
+	conn, err := mgmt.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dev.next(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.Restart(); err != nil {
+		t.Fatal(err)
+	}
+
+	if unit, err := dev.next(); err != nil {
+		t.Fatal(err)
+	} else if app, ok := unit.(*cemi.AppData); !ok || !app.Numbered || app.Command != cemi.Restart {
+		t.Errorf("Expected restart request, got %+v", unit)
+	}
+
+	if _, err := conn.ReadMemory(0, 1); err != errDeviceDisconnected {
+		t.Errorf("Expected error %v, got %v", errDeviceDisconnected, err)
+	}
+}
+
+func TestDeviceConn_AckTimeout(t *testing.T) {
+	client := newDummyClient()
+	defer close(client.inbound)
+
+	addr := cemi.NewIndividualAddr3(1, 1, 5)
+	mgmt := newManagement(client, ManagementConfig{AckTimeout: time.Millisecond, MaxRepetitions: 2})
+
+	conn, err := mgmt.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := conn.ReadMemory(0, 1); err != errAckTimeout {
+		t.Fatalf("Expected error %v, got %v", errAckTimeout, err)
+	}
+
+	// T_Connect, three attempts and T_Disconnect
+	if len(client.outbound) != 5 {
+		t.Errorf("Unexpected number of outgoing messages: %d", len(client.outbound))
+	}
+}
+
+func TestCheckManagementConfig(t *testing.T) {
+	if config := checkManagementConfig(ManagementConfig{}); config != DefaultManagementConfig {
+		t.Errorf("Zero configuration should use the defaults: %+v", config)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"sync"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+// A GroupMatcher selects group events.
+type GroupMatcher func(event GroupEvent) bool
+
+// MatchAny matches every event.
+func MatchAny(event GroupEvent) bool {
+	return true
+}
+
+// MatchAddr matches events whose destination is the given address.
+func MatchAddr(addr cemi.GroupAddr) GroupMatcher {
+	return func(event GroupEvent) bool {
+		return event.Destination == addr
+	}
+}
+
+// MatchMainGroup matches events whose destination is in the given main group.
+func MatchMainGroup(main uint8) GroupMatcher {
+	return func(event GroupEvent) bool {
+		return uint8(event.Destination>>11)&0x1F == main
+	}
+}
+
+// MatchMiddleGroup matches events whose destination is in the given middle group.
+func MatchMiddleGroup(main, middle uint8) GroupMatcher {
+	return func(event GroupEvent) bool {
+		return uint8(event.Destination>>8) == (main&0x1F)<<3|middle&0x7
+	}
+}
+
+// MatchCommand matches events with the given command.
+func MatchCommand(cmd GroupCommand) GroupMatcher {
+	return func(event GroupEvent) bool {
+		return event.Command == cmd
+	}
+}
+
+// MatchAll matches events which are matched by all given matchers.
+func MatchAll(matchers ...GroupMatcher) GroupMatcher {
+	return func(event GroupEvent) bool {
+		for _, match := range matchers {
+			if !match(event) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// MatchRange matches events whose destination is in the given range.
+func MatchRange(r cemi.GroupAddrRange) GroupMatcher {
+	return func(event GroupEvent) bool {
+		return r.Contains(event.Destination)
+	}
+}
+
+// MatchPattern creates a matcher from a pattern. It supports the notations of
+// cemi.NewGroupAddrRangeString, e.g. "*", "1/*", "1/2/*", "1/2/10-20" and "1/2/3".
+func MatchPattern(pattern string) (GroupMatcher, error) {
+	r, err := cemi.NewGroupAddrRangeString(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return MatchRange(r), nil
+}
+
+// groupMuxSub is a subscriber of a GroupMux.
+type groupMuxSub struct {
+	match  GroupMatcher
+	events chan GroupEvent
+}
+
+// DefaultGroupMuxQueueSize is the queue size used if none is given to NewGroupMux.
+const DefaultGroupMuxQueueSize = 64
+
+// A GroupMux distributes the inbound events of a group client to multiple subscribers. Each
+// subscriber has its own queue. Events are dropped for subscribers whose queue is full, so that
+// slow subscribers do not hold up the others.
+type GroupMux struct {
+	client    GroupClient
+	queueSize int
+
+	mu      sync.Mutex
+	subs    map[*groupMuxSub]struct{}
+	dropped uint64
+	closed  bool
+}
+
+// NewGroupMux creates a mux which consumes the inbound events of the given client. queueSize
+// determines the number of events each subscriber can hold, 0 selects DefaultGroupMuxQueueSize.
+func NewGroupMux(client GroupClient, queueSize int) *GroupMux {
+	if queueSize <= 0 {
+		queueSize = DefaultGroupMuxQueueSize
+	}
+
+	mux := &GroupMux{
+		client:    client,
+		queueSize: queueSize,
+		subs:      make(map[*groupMuxSub]struct{}),
+	}
+
+	go mux.serve()
+
+	return mux
+}
+
+// serve distributes the inbound events.
+func (mux *GroupMux) serve() {
+	for event := range mux.client.Inbound() {
+		mux.mu.Lock()
+
+		for sub := range mux.subs {
+			if !sub.match(event) {
+				continue
+			}
+
+			select {
+			case sub.events <- event:
+			default:
+				mux.dropped++
+			}
+		}
+
+		mux.mu.Unlock()
+	}
+
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+
+	mux.closed = true
+
+	for sub := range mux.subs {
+		delete(mux.subs, sub)
+		close(sub.events)
+	}
+}
+
+// subscribe registers a new subscriber.
+func (mux *GroupMux) subscribe(match GroupMatcher) (*groupMuxSub, func()) {
+	sub := &groupMuxSub{
+		match:  match,
+		events: make(chan GroupEvent, mux.queueSize),
+	}
+
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+
+	if mux.closed {
+		close(sub.events)
+	} else {
+		mux.subs[sub] = struct{}{}
+	}
+
+	unsubscribe := func() {
+		mux.mu.Lock()
+		defer mux.mu.Unlock()
+
+		if _, ok := mux.subs[sub]; ok {
+			delete(mux.subs, sub)
+			close(sub.events)
+		}
+	}
+
+	return sub, unsubscribe
+}
+
+// Subscribe returns a channel which receives the events selected by the matcher. The channel is
+// closed after calling the returned unsubscribe function or when the client's inbound channel has
+// been closed.
+func (mux *GroupMux) Subscribe(match GroupMatcher) (<-chan GroupEvent, func()) {
+	sub, unsubscribe := mux.subscribe(match)
+	return sub.events, unsubscribe
+}
+
+// Handle calls the handler for each event selected by the matcher. The handler runs in its own
+// goroutine. It is not called anymore after the returned unsubscribe function has been called
+// and the queued events have been handled.
+func (mux *GroupMux) Handle(match GroupMatcher, handler func(GroupEvent)) func() {
+	sub, unsubscribe := mux.subscribe(match)
+
+	go func() {
+		for event := range sub.events {
+			handler(event)
+		}
+	}()
+
+	return unsubscribe
+}
+
+// A GroupMuxClient is a subscription of a GroupMux which implements GroupClient. This lets
+// consumers like GroupCache, GroupDecoder or VirtualDevice share one connection.
+type GroupMuxClient struct {
+	mux         *GroupMux
+	events      <-chan GroupEvent
+	unsubscribe func()
+}
+
+// Client subscribes to the events selected by the matcher and returns the subscription as a
+// GroupClient. Events are sent using the underlying client. Like any subscriber, the client misses
+// events while its queue is full.
+func (mux *GroupMux) Client(match GroupMatcher) *GroupMuxClient {
+	events, unsubscribe := mux.Subscribe(match)
+	return &GroupMuxClient{mux: mux, events: events, unsubscribe: unsubscribe}
+}
+
+// Send a group event using the underlying client of the mux.
+func (client *GroupMuxClient) Send(event GroupEvent) error {
+	return client.mux.Send(event)
+}
+
+// Inbound returns the channel on which the selected events are received. It is closed after Close
+// has been called or when the inbound channel of the underlying client has been closed.
+func (client *GroupMuxClient) Inbound() <-chan GroupEvent {
+	return client.events
+}
+
+// Close unsubscribes the client from the mux. The underlying client remains open.
+func (client *GroupMuxClient) Close() {
+	client.unsubscribe()
+}
+
+// Dropped returns the total number of events which have been dropped because of full queues.
+func (mux *GroupMux) Dropped() uint64 {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+
+	return mux.dropped
+}
+
+// Send a group event using the underlying client.
+func (mux *GroupMux) Send(event GroupEvent) error {
+	return mux.client.Send(event)
+}
@@ -0,0 +1,162 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		addr    cemi.GroupAddr
+		match   bool
+	}{
+		{"*", cemi.NewGroupAddr3(31, 7, 255), true},
+		{"1/*", cemi.NewGroupAddr3(1, 7, 255), true},
+		{"1/*/*", cemi.NewGroupAddr3(2, 0, 0), false},
+		{"1/2/*", cemi.NewGroupAddr3(1, 2, 0), true},
+		{"1/2/*", cemi.NewGroupAddr3(1, 3, 0), false},
+		{"1/2/3", cemi.NewGroupAddr3(1, 2, 3), true},
+		{"1/2/3", cemi.NewGroupAddr3(1, 2, 4), false},
+		{"1/2/10-20", cemi.NewGroupAddr3(1, 2, 20), true},
+		{"1/2/10-20", cemi.NewGroupAddr3(1, 2, 21), false},
+		{"1/515", cemi.NewGroupAddr3(1, 2, 3), true},
+	}
+
+	for _, c := range cases {
+		match, err := MatchPattern(c.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if match(GroupEvent{Destination: c.addr}) != c.match {
+			t.Errorf("Pattern %s should match %v: %v", c.pattern, c.addr, c.match)
+		}
+	}
+
+	for _, invalid := range []string{"", "32/*", "1/8/*", "1/2/3/4", "x/*", "1/*/3"} {
+		if _, err := MatchPattern(invalid); err == nil {
+			t.Errorf("Pattern %q should be invalid", invalid)
+		}
+	}
+}
+
+func TestGroupMux(t *testing.T) {
+	client := newDummyGroupClient()
+	mux := NewGroupMux(client, 1)
+
+	middle, err := MatchPattern("1/2/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lights, unsubscribeLights := mux.Subscribe(middle)
+	writes, unsubscribeWrites := mux.Subscribe(MatchCommand(GroupWrite))
+	defer unsubscribeWrites()
+
+	// The handler blocks until released, which must not affect the other subscribers.
+	release := make(chan struct{})
+	handled := make(chan GroupEvent, 10)
+	mux.Handle(MatchAny, func(event GroupEvent) {
+		<-release
+		handled <- event
+	})
+
+	client.inbound <- GroupEvent{Command: GroupWrite, Destination: cemi.NewGroupAddr3(1, 2, 3)}
+
+	if event := <-lights; event.Destination != cemi.NewGroupAddr3(1, 2, 3) {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	if event := <-writes; event.Command != GroupWrite {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	unsubscribeLights()
+	unsubscribeLights()
+
+	if _, ok := <-lights; ok {
+		t.Error("Channel should be closed after unsubscribing")
+	}
+
+	client.inbound <- GroupEvent{Command: GroupRead, Destination: cemi.NewGroupAddr3(1, 2, 4)}
+	client.inbound <- GroupEvent{Command: GroupWrite, Destination: cemi.NewGroupAddr3(3, 0, 1)}
+
+	select {
+	case event := <-writes:
+		if event.Destination != cemi.NewGroupAddr3(3, 0, 1) {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("Subscriber is blocked by the slow handler")
+	}
+
+	close(release)
+	close(client.inbound)
+
+	<-handled
+
+	if dropped := mux.Dropped(); dropped == 0 {
+		t.Error("Events should have been dropped for the slow handler")
+	}
+}
+
+func TestGroupMux_Client(t *testing.T) {
+	client := newDummyGroupClient()
+	mux := NewGroupMux(client, 0)
+
+	addr := cemi.NewGroupAddr3(1, 2, 3)
+
+	cache := NewGroupCache(mux.Client(MatchAny), GroupCacheConfig{})
+
+	decoder := NewGroupDecoder(mux.Client(MatchAddr(addr)), map[cemi.GroupAddr]string{addr: "1.001"})
+
+	other := mux.Client(MatchAddr(cemi.NewGroupAddr3(3, 0, 1)))
+
+	client.inbound <- GroupEvent{Command: GroupWrite, Destination: addr, Data: []byte{1}}
+
+	select {
+	case event := <-decoder.Inbound():
+		if event.Destination != addr {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("Decoder did not receive the event")
+	}
+
+	for {
+		if state, ok := cache.Get(addr); ok {
+			if !bytes.Equal(state.Data, []byte{1}) {
+				t.Errorf("Unexpected state: %+v", state)
+			}
+
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := other.Send(GroupEvent{Command: GroupRead, Destination: addr}); err != nil {
+		t.Fatal(err)
+	}
+
+	if event := <-client.outbound; event.Command != GroupRead || event.Destination != addr {
+		t.Errorf("Unexpected outbound event: %+v", event)
+	}
+
+	other.Close()
+
+	if _, ok := <-other.Inbound(); ok {
+		t.Error("Channel should be closed after closing the client")
+	}
+
+	close(client.inbound)
+}
@@ -0,0 +1,387 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+// Package rf provides the means to parse and generate KNX RF 1.1 and RF Multi data link frames.
+package rf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+// CRC computes the CRC-16 that protects each block of a frame. It uses the polynomial 0x3D65 and
+// an inverted result, as specified in EN 13757-4.
+func CRC(data []byte) uint16 {
+	var crc uint16
+
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x3D65
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return ^crc
+}
+
+const (
+	// controlField is the C-field of KNX RF data frames.
+	controlField = 0x44
+
+	// escField identifies KNX frames among wireless M-Bus frames.
+	escField = 0xFF
+
+	// headerSize is the size of the first block without its CRC.
+	headerSize = 10
+
+	// blockSize is the maximum size of the following blocks without their CRC.
+	blockSize = 16
+)
+
+// A Frame is a KNX RF data link frame.
+type Frame struct {
+	RSS              cemi.SignalStrength
+	RetransmitterRSS cemi.SignalStrength
+
+	// Set if the battery state of the device is OK, cleared if it is low
+	BatteryOK bool
+
+	// Unidirectional devices can only send. They are addressed using their serial number.
+	Unidirectional bool
+
+	// DomainAddr is the address extension type. It determines whether SerialNumber holds the
+	// domain address of the sender instead of its serial number.
+	DomainAddr bool
+
+	// Serial number of the sender, or the domain address if DomainAddr is set
+	SerialNumber cemi.SerialNumber
+
+	// Link layer frame number, used to detect repetitions
+	LFN uint8
+
+	// RF Multi extension bits in the upper half of the KNX-Ctrl field; 0 for KNX RF 1.1
+	Multi uint8
+
+	Source      cemi.IndividualAddr
+	Destination uint16
+
+	// GroupAddr determines whether the destination is a group address.
+	GroupAddr bool
+
+	// Repetition counter of the L/NPCI field; 0 for original frames
+	Repetition uint8
+
+	// Extended frame format of the L/NPCI field; 0 for standard frames
+	ExtendedFormat uint8
+
+	// Transport layer data unit, i.e. TPCI, APCI and data
+	TPDU []byte
+}
+
+// rfInfo generates the RF-Info field.
+func (frame *Frame) rfInfo() byte {
+	info := byte(frame.RSS&3)<<4 | byte(frame.RetransmitterRSS&3)<<2
+
+	if frame.BatteryOK {
+		info |= 1 << 1
+	}
+
+	if frame.Unidirectional {
+		info |= 1
+	}
+
+	return info
+}
+
+// knxCtrl generates the KNX-Ctrl field.
+func (frame *Frame) knxCtrl() byte {
+	ctrl := (frame.Multi&15)<<4 | (frame.LFN&7)<<1
+
+	if frame.DomainAddr {
+		ctrl |= 1
+	}
+
+	return ctrl
+}
+
+// lnpci generates the L/NPCI field.
+func (frame *Frame) lnpci() byte {
+	npci := (frame.Repetition&7)<<4 | frame.ExtendedFormat&15
+
+	if frame.GroupAddr {
+		npci |= 1 << 7
+	}
+
+	return npci
+}
+
+// dataSize returns the size of the data following the first block, without CRCs.
+func (frame *Frame) dataSize() uint {
+	return 6 + uint(len(frame.TPDU))
+}
+
+// Size returns the packed size.
+func (frame *Frame) Size() uint {
+	dataSize := frame.dataSize()
+	blocks := (dataSize + blockSize - 1) / blockSize
+
+	return headerSize + 2 + dataSize + 2*blocks
+}
+
+// Pack the frame into the buffer.
+func (frame *Frame) Pack(buffer []byte) {
+	buffer[0] = byte(headerSize - 1 + frame.dataSize())
+	buffer[1] = controlField
+	buffer[2] = escField
+	buffer[3] = frame.rfInfo()
+	copy(buffer[4:10], frame.SerialNumber[:])
+	putCRC(buffer[10:], buffer[:10])
+
+	data := make([]byte, 0, frame.dataSize())
+	data = append(data, frame.knxCtrl(),
+		byte(frame.Source>>8), byte(frame.Source),
+		byte(frame.Destination>>8), byte(frame.Destination),
+		frame.lnpci())
+	data = append(data, frame.TPDU...)
+
+	offset := headerSize + 2
+
+	for len(data) > 0 {
+		n := len(data)
+		if n > blockSize {
+			n = blockSize
+		}
+
+		copy(buffer[offset:], data[:n])
+		putCRC(buffer[offset+n:], data[:n])
+
+		offset += n + 2
+		data = data[n:]
+	}
+}
+
+// putCRC writes the CRC of the block into the buffer.
+func putCRC(buffer []byte, block []byte) {
+	crc := CRC(block)
+	buffer[0] = byte(crc >> 8)
+	buffer[1] = byte(crc)
+}
+
+// checkCRC verifies the CRC that follows the block.
+func checkCRC(block []byte, crc []byte) error {
+	if expected := CRC(block); uint16(crc[0])<<8|uint16(crc[1]) != expected {
+		return fmt.Errorf("block CRC mismatch: expected %#04x, got %#02x%02x", expected, crc[0], crc[1])
+	}
+
+	return nil
+}
+
+// Unpack initializes the frame by parsing the given data.
+func (frame *Frame) Unpack(data []byte) (uint, error) {
+	if len(data) < headerSize+2 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	if data[1] != controlField || data[2] != escField {
+		return 0, fmt.Errorf("not a KNX RF data frame (C-field %#02x, Esc-field %#02x)", data[1], data[2])
+	}
+
+	if err := checkCRC(data[:headerSize], data[headerSize:]); err != nil {
+		return 0, err
+	}
+
+	if int(data[0]) < headerSize-1+6 {
+		return 0, fmt.Errorf("frame length %d is too short", data[0])
+	}
+
+	dataSize := int(data[0]) - (headerSize - 1)
+	payload := make([]byte, 0, dataSize)
+	offset := headerSize + 2
+
+	for len(payload) < dataSize {
+		n := dataSize - len(payload)
+		if n > blockSize {
+			n = blockSize
+		}
+
+		if len(data) < offset+n+2 {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		if err := checkCRC(data[offset:offset+n], data[offset+n:]); err != nil {
+			return 0, err
+		}
+
+		payload = append(payload, data[offset:offset+n]...)
+		offset += n + 2
+	}
+
+	info := data[3]
+
+	*frame = Frame{
+		RSS:              cemi.SignalStrength(info>>4) & 3,
+		RetransmitterRSS: cemi.SignalStrength(info>>2) & 3,
+		BatteryOK:        info&(1<<1) != 0,
+		Unidirectional:   info&1 != 0,
+		Multi:            payload[0] >> 4,
+		LFN:              (payload[0] >> 1) & 7,
+		DomainAddr:       payload[0]&1 != 0,
+		Source:           cemi.IndividualAddr(payload[1])<<8 | cemi.IndividualAddr(payload[2]),
+		Destination:      uint16(payload[3])<<8 | uint16(payload[4]),
+		GroupAddr:        payload[5]&(1<<7) != 0,
+		Repetition:       (payload[5] >> 4) & 7,
+		ExtendedFormat:   payload[5] & 15,
+		TPDU:             payload[6:],
+	}
+
+	copy(frame.SerialNumber[:], data[4:10])
+
+	return uint(offset), nil
+}
+
+// isSysBroadcast determines if the frame is a system broadcast. RF marks these as broadcasts that
+// are addressed using the serial number instead of the domain address.
+func (frame *Frame) isSysBroadcast() bool {
+	return !frame.DomainAddr && frame.GroupAddr && frame.Destination == 0
+}
+
+// LData converts the frame to a L_Data frame. The RF specific fields are stored in a
+// cemi.RFMediumInfo entry of the additional info.
+func (frame *Frame) LData() (cemi.LData, error) {
+	if len(frame.TPDU) < 1 {
+		return cemi.LData{}, errors.New("frame does not contain a transport unit")
+	}
+
+	ctrl1 := cemi.Control1NoRepeat | cemi.Control1NoSysBroadcast | cemi.Control1Prio(cemi.PrioLow)
+	ctrl1.SetRepeat(frame.Repetition != 0)
+	ctrl1.SetSysBroadcast(frame.isSysBroadcast())
+
+	ctrl2 := cemi.Control2Hops(6)
+	ctrl2.SetGroupAddr(frame.GroupAddr)
+	ctrl2.SetExtendedFormat(frame.ExtendedFormat)
+
+	// Reuse the cEMI parser by assembling a L_Data frame without additional info.
+	data := make([]byte, 8+len(frame.TPDU))
+	data[1] = byte(ctrl1)
+	data[2] = byte(ctrl2)
+	data[3] = byte(frame.Source >> 8)
+	data[4] = byte(frame.Source)
+	data[5] = byte(frame.Destination >> 8)
+	data[6] = byte(frame.Destination)
+	data[7] = byte(len(frame.TPDU) - 1)
+	copy(data[8:], frame.TPDU)
+
+	var ldata cemi.LData
+	if _, err := ldata.Unpack(data); err != nil {
+		return cemi.LData{}, err
+	}
+
+	ldata.Info = cemi.NewInfo(&cemi.RFMediumInfo{
+		RSS:              frame.RSS,
+		RetransmitterRSS: frame.RetransmitterRSS,
+		BatteryOK:        frame.BatteryOK,
+		Unidirectional:   frame.Unidirectional,
+		SerialNumber:     frame.SerialNumber,
+		LFN:              frame.LFN,
+	})
+	ldata.SetFrameType()
+
+	return ldata, nil
+}
+
+// FromLData generates a frame for the given L_Data frame. The RF specific fields are taken from
+// the cemi.RFMediumInfo entry of the additional info, if there is one. Without it, the frame is
+// sent bidirectionally with LFN 0 and no serial number. Bidirectional frames use domain address
+// mode, except for system broadcasts. The frame is always generated as an original frame, i.e.
+// with a repetition counter of 0.
+func FromLData(ldata *cemi.LData) (*Frame, error) {
+	if ldata.Data == nil {
+		return nil, errors.New("L_Data frame does not contain a transport unit")
+	}
+
+	if ldata.Control2.IsLTE() {
+		return nil, errors.New("LTE frames cannot be transmitted via RF")
+	}
+
+	entries, err := ldata.Info.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	frame := &Frame{
+		Source:         ldata.Source,
+		Destination:    ldata.Destination,
+		GroupAddr:      ldata.Control2.IsGroupAddr(),
+		ExtendedFormat: ldata.Control2.ExtendedFormat(),
+	}
+
+	for _, entry := range entries {
+		if info, ok := entry.(*cemi.RFMediumInfo); ok {
+			frame.RSS = info.RSS
+			frame.RetransmitterRSS = info.RetransmitterRSS
+			frame.BatteryOK = info.BatteryOK
+			frame.Unidirectional = info.Unidirectional
+			frame.SerialNumber = info.SerialNumber
+			frame.LFN = info.LFN
+			break
+		}
+	}
+
+	sysBroadcast := ldata.Control1.SysBroadcast() && frame.GroupAddr && frame.Destination == 0
+	frame.DomainAddr = !frame.Unidirectional && !sysBroadcast
+
+	// Strip the length field.
+	tpdu := make([]byte, ldata.Data.Size())
+	ldata.Data.Pack(tpdu)
+	frame.TPDU = tpdu[1:]
+
+	if frame.dataSize() > 255-(headerSize-1) {
+		return nil, fmt.Errorf("transport unit of %d bytes is too large", len(frame.TPDU))
+	}
+
+	return frame, nil
+}
+
+// unpackWithInfo parses a message body that consists of additional info and a frame.
+func unpackWithInfo(data []byte) (*Frame, cemi.Info, error) {
+	var info cemi.Info
+
+	n, err := info.Unpack(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	frame := &Frame{}
+	if _, err := frame.Unpack(data[n:]); err != nil {
+		return nil, nil, err
+	}
+
+	return frame, info, nil
+}
+
+// UnpackBusmon parses the frame inside a L_Busmon.ind message. The additional info is returned
+// as well.
+func UnpackBusmon(ind cemi.LBusmonInd) (*Frame, cemi.Info, error) {
+	return unpackWithInfo(ind)
+}
+
+// UnpackRaw parses the frame inside a L_Raw message body.
+func UnpackRaw(raw cemi.LRaw) (*Frame, cemi.Info, error) {
+	return unpackWithInfo(raw)
+}
+
+// NewLRaw generates a L_Raw message body without additional info for the given frame.
+func NewLRaw(frame *Frame) cemi.LRaw {
+	buffer := make([]byte, 1+frame.Size())
+	frame.Pack(buffer[1:])
+
+	return cemi.LRaw(buffer)
+}
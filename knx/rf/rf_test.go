@@ -0,0 +1,167 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package rf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+func TestCRC(t *testing.T) {
+	if crc := CRC([]byte("123456789")); crc != 0xC2B7 {
+		t.Errorf("Unexpected CRC %#04x", crc)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	for _, length := range []int{1, 2, 11, 12, 30} {
+		tpdu := make([]byte, length)
+		for i := range tpdu {
+			tpdu[i] = byte(i + 1)
+		}
+
+		frame := Frame{
+			RSS:            cemi.SignalStrong,
+			BatteryOK:      true,
+			Unidirectional: true,
+			SerialNumber:   cemi.SerialNumber{0x00, 0xFA, 0x12, 0x34, 0x56, 0x78},
+			LFN:            5,
+			Source:         cemi.NewIndividualAddr3(1, 1, 1),
+			Destination:    uint16(cemi.NewGroupAddr3(1, 2, 3)),
+			GroupAddr:      true,
+			Repetition:     2,
+			TPDU:           tpdu,
+		}
+
+		buffer := make([]byte, frame.Size())
+		frame.Pack(buffer)
+
+		if int(buffer[0]) != 9+6+length {
+			t.Errorf("Unexpected length field %d", buffer[0])
+		}
+
+		if buffer[3]&(1<<1) == 0 {
+			t.Errorf("Battery state should be OK in RF-Info %#02x", buffer[3])
+		}
+
+		var parsed Frame
+		if n, err := parsed.Unpack(buffer); err != nil {
+			t.Fatal(err)
+		} else if n != uint(len(buffer)) {
+			t.Errorf("Unexpected length %d, expected %d", n, len(buffer))
+		}
+
+		if !reflect.DeepEqual(parsed, frame) {
+			t.Errorf("Mismatch:\n%+v\n%+v", parsed, frame)
+		}
+
+		buffer[len(buffer)-3] ^= 1
+		if _, err := parsed.Unpack(buffer); err == nil {
+			t.Error("Should not succeed with corrupted block")
+		}
+	}
+}
+
+func TestFrame_Telegram(t *testing.T) {
+	// GroupValueWrite of 1 to 0/0/1 from 0.5.1, sent by a unidirectional device with serial
+	// number 00FA:12345678 and LFN 2
+	telegram := []byte{
+		0x11, 0x44, 0xFF, 0x01, 0x00, 0xFA, 0x12, 0x34, 0x56, 0x78, 0x16, 0x07,
+		0x04, 0x05, 0x01, 0x00, 0x01, 0x80, 0x00, 0x81, 0x6F, 0xA0,
+	}
+
+	expected := Frame{
+		Unidirectional: true,
+		SerialNumber:   cemi.SerialNumber{0x00, 0xFA, 0x12, 0x34, 0x56, 0x78},
+		LFN:            2,
+		Source:         cemi.NewIndividualAddr3(0, 5, 1),
+		Destination:    uint16(cemi.NewGroupAddr3(0, 0, 1)),
+		GroupAddr:      true,
+		TPDU:           []byte{0x00, 0x81},
+	}
+
+	var frame Frame
+	if n, err := frame.Unpack(telegram); err != nil {
+		t.Fatal(err)
+	} else if n != uint(len(telegram)) {
+		t.Errorf("Unexpected length %d, expected %d", n, len(telegram))
+	}
+
+	if !reflect.DeepEqual(frame, expected) {
+		t.Errorf("Mismatch:\n%+v\n%+v", frame, expected)
+	}
+
+	buffer := make([]byte, expected.Size())
+	expected.Pack(buffer)
+
+	if !bytes.Equal(buffer, telegram) {
+		t.Errorf("Mismatch:\n% x\n% x", buffer, telegram)
+	}
+
+	ldata, err := frame.LData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ldata.Control1.SysBroadcast() || ldata.Control1.Repeat() || !ldata.Control2.IsGroupAddr() {
+		t.Errorf("Unexpected control fields %v %v", ldata.Control1, ldata.Control2)
+	}
+
+	if app, ok := ldata.Data.(*cemi.AppData); !ok || app.Command != cemi.GroupValueWrite || !bytes.Equal(app.Data, []byte{1}) {
+		t.Errorf("Unexpected transport unit %+v", ldata.Data)
+	}
+}
+
+func TestLData(t *testing.T) {
+	ldata := cemi.LData{
+		Control1:    cemi.Control1StdFrame | cemi.Control1NoRepeat | cemi.Control1NoSysBroadcast | cemi.Control1Prio(cemi.PrioLow),
+		Control2:    cemi.Control2GroupAddr | cemi.Control2Hops(6),
+		Source:      cemi.NewIndividualAddr3(1, 1, 1),
+		Destination: uint16(cemi.NewGroupAddr3(0, 0, 1)),
+		Data:        &cemi.AppData{Command: cemi.GroupValueWrite, Data: []byte{1}},
+		Info: cemi.NewInfo(&cemi.RFMediumInfo{
+			RSS:            cemi.SignalMedium,
+			Unidirectional: true,
+			SerialNumber:   cemi.SerialNumber{1, 2, 3, 4, 5, 6},
+			LFN:            3,
+		}),
+	}
+
+	frame, err := FromLData(&ldata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !frame.Unidirectional || frame.LFN != 3 || frame.RSS != cemi.SignalMedium || !frame.GroupAddr {
+		t.Errorf("RF info has not been applied: %+v", frame)
+	}
+
+	if frame.DomainAddr {
+		t.Error("Unidirectional frames must be addressed using the serial number")
+	}
+
+	raw := NewLRaw(frame)
+
+	parsed, _, err := UnpackRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := parsed.LData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(result, ldata) {
+		t.Errorf("Mismatch:\n%+v\n%+v", result, ldata)
+	}
+
+	ldata.Control2 = cemi.Control2LTE(cemi.LTEGeographical)
+	if _, err := FromLData(&ldata); err == nil {
+		t.Error("Should not succeed with LTE frame")
+	}
+}
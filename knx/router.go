@@ -5,13 +5,15 @@ package knx
 
 import (
 	"container/list"
+	"context"
 	"errors"
-	"math/rand"
+	"math"
 	"net"
 	"sync"
 	"time"
 
 	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
 	"github.com/vapourismo/knx-go/knx/knxnet"
 	"github.com/vapourismo/knx-go/knx/util"
 )
@@ -24,12 +26,37 @@ type RouterConfig struct {
 	// Specifies the interface used to send and receive KNXnet/IP packets. If the interface
 	// is nil, the system-assigned multicast interface is used.
 	Interface *net.Interface
+	// Interfaces specifies further interfaces on which the Router operates. Packets are sent on
+	// each interface.
+	Interfaces []*net.Interface
+	// MulticastTTL is the time-to-live of outgoing packets. 0 keeps the system default of 1.
+	MulticastTTL int
+	// ReportInterface delivers inbound messages on the Frames channel instead of the Inbound
+	// channel, together with the interface on which they have arrived. NewGroupRouter rejects it.
+	ReportInterface bool
 	// Specifies if Multicast Loopback should be enabled.
 	MulticastLoopbackEnabled bool
 	// Pause duration after sending. 0 means disabled.
 	// According to the specification, we may choose to always pause for 20 ms // after transmitting,
 	// bu we should always pause for at least 5 ms on a multicast address.
 	PostSendPauseDuration time.Duration
+	// MaxAPDULength limits the APDU length of outgoing L_Data frames. 0 permits up to
+	// cemi.MaxExtendedAPDULength.
+	MaxAPDULength uint
+	// GroupReadTimeout specifies how long GroupRouter.ReadGroup waits for a response before it
+	// repeats the request.
+	GroupReadTimeout time.Duration
+	// GroupReadAttempts is the number of requests GroupRouter.ReadGroup sends at most.
+	GroupReadAttempts uint
+	// Scheduler enables the outbound send scheduler if it is not nil. Send then only queues the
+	// frame; transmission errors are reflected in the scheduler statistics.
+	Scheduler *SchedulerConfig
+	// InboundFilter enables the deduplication of inbound frames and the suppression of echoes of
+	// our own frames if it is not nil. Echoes occur with MulticastLoopbackEnabled.
+	InboundFilter *InboundFilterConfig
+	// FlowControl enables the inbound queue and the signalling of its congestion to other routers
+	// if it is not nil. Frames are dropped if the inbound channel is not consumed in time.
+	FlowControl *FlowControlConfig
 }
 
 // DefaultRouterConfig is a good default configuration for a Router client.
@@ -37,6 +64,8 @@ var DefaultRouterConfig = RouterConfig{
 	RetainCount:              32,
 	MulticastLoopbackEnabled: false,
 	PostSendPauseDuration:    20 * time.Millisecond,
+	GroupReadTimeout:         2 * time.Second,
+	GroupReadAttempts:        3,
 }
 
 // checkRouterConfig validates the given RouterConfig.
@@ -45,18 +74,45 @@ func checkRouterConfig(config RouterConfig) RouterConfig {
 		config.RetainCount = DefaultRouterConfig.RetainCount
 	}
 
+	if config.GroupReadTimeout <= 0 {
+		config.GroupReadTimeout = DefaultRouterConfig.GroupReadTimeout
+	}
+
+	if config.GroupReadAttempts == 0 {
+		config.GroupReadAttempts = DefaultRouterConfig.GroupReadAttempts
+	}
+
 	return config
 }
 
+// A RouterFrame is an inbound message together with the interface on which it has arrived.
+type RouterFrame struct {
+	Message cemi.Message
+
+	// Interface is nil if it is unknown.
+	Interface *net.Interface
+}
+
 // A Router provides the means to communicate with KNXnet/IP routers in a IP multicast group.
 // It supports sending and receiving CEMI-encoded frames, aswell as basic flow control.
 type Router struct {
 	sock          knxnet.Socket
 	config        RouterConfig
 	inbound       chan cemi.Message
+	frames        chan RouterFrame
 	sendMu        sync.Mutex
 	retainer      *list.List
 	postSendPause time.Duration
+	scheduler     *Scheduler
+	filter        *inboundFilter
+	flowControl   *FlowControlConfig
+	now           func() time.Time
+	busyUntil     time.Time
+	backoff       *busyBackoff
+
+	lostMu    sync.Mutex
+	lost      uint16
+	lostTimer *time.Timer
 }
 
 // sendMultiple sends each message from the slice. Doesn't matter if one fails, all will be tried.
@@ -87,24 +143,128 @@ func (router *Router) resendLost(count uint16) {
 	go router.sendMultiple(messages)
 }
 
+// tryPushInbound passes the frame to the consumer without blocking.
+func (router *Router) tryPushInbound(frame RouterFrame) bool {
+	if router.frames != nil {
+		select {
+		case router.frames <- frame:
+			return true
+		default:
+			return false
+		}
+	}
+
+	select {
+	case router.inbound <- frame.Message:
+		return true
+	default:
+		return false
+	}
+}
+
+// queuedInbound returns the number of frames which wait for the consumer.
+func (router *Router) queuedInbound() int {
+	if router.frames != nil {
+		return len(router.frames)
+	}
+
+	return len(router.inbound)
+}
+
 // pushInbound sends the message through the inbound channel. If the sending blocks, it will launch
 // a goroutine which will do the sending.
-func (router *Router) pushInbound(msg cemi.Message) {
-	select {
-	case router.inbound <- msg:
+func (router *Router) pushInbound(frame RouterFrame) {
+	if router.filter != nil && router.filter.drop(frame.Message) {
+		return
+	}
 
-	default:
-		go func() {
-			// Since this goroutine decouples from the server goroutine, it might try to send when
-			// the server closed the inbound channel. Sending to a closed channel will panic. But we
-			// don't care, because cool guys don't look at explosions.
-			defer func() { recover() }()
-			router.inbound <- msg
-		}()
+	if router.flowControl != nil {
+		router.queueInbound(frame)
+		return
+	}
+
+	if router.tryPushInbound(frame) {
+		return
+	}
+
+	go func() {
+		// Since this goroutine decouples from the server goroutine, it might try to send when
+		// the server closed the inbound channel. Sending to a closed channel will panic. But we
+		// don't care, because cool guys don't look at explosions.
+		defer func() { recover() }()
+
+		if router.frames != nil {
+			router.frames <- frame
+		} else {
+			router.inbound <- frame.Message
+		}
+	}()
+}
+
+// queueInbound adds the message to the inbound queue. Other routers are asked to pause when the
+// queue is about to overflow. Messages that do not fit are dropped and reported as lost.
+func (router *Router) queueInbound(frame RouterFrame) {
+	if router.tryPushInbound(frame) {
+		if uint(router.queuedInbound()) >= router.flowControl.BusyThreshold {
+			router.signalBusy()
+		}
+
+		return
 	}
+
+	util.Log(router, "Inbound queue is full, dropping frame")
+	router.countLost()
 }
 
-const maxWaitTime = 50 * time.Millisecond
+// countLost counts a dropped frame. The dropped frames are reported together, so that a congested
+// network does not have to carry one ROUTING_LOST_MESSAGE per frame.
+func (router *Router) countLost() {
+	router.lostMu.Lock()
+	defer router.lostMu.Unlock()
+
+	if router.lost < math.MaxUint16 {
+		router.lost++
+	}
+
+	if router.lostTimer == nil {
+		router.lostTimer = time.AfterFunc(router.flowControl.BusyWaitTime, router.reportLost)
+	}
+}
+
+// reportLost sends a single ROUTING_LOST_MESSAGE with the number of frames dropped since the last
+// report.
+func (router *Router) reportLost() {
+	router.lostMu.Lock()
+	count := router.lost
+	router.lost = 0
+	router.lostTimer = nil
+	router.lostMu.Unlock()
+
+	if count == 0 {
+		return
+	}
+
+	err := router.sock.Send(&knxnet.RoutingLost{Status: knxnet.DeviceStateOk, Count: count})
+	if err != nil {
+		util.Log(router, "Failed to send routing lost message: %v", err)
+	}
+}
+
+// signalBusy sends a routing busy indication unless the previous one is still in effect.
+func (router *Router) signalBusy() {
+	now := router.now()
+	if now.Before(router.busyUntil) {
+		return
+	}
+
+	waitTime := router.flowControl.BusyWaitTime
+	router.busyUntil = now.Add(waitTime)
+
+	err := router.sock.Send(&knxnet.RoutingBusy{Status: knxnet.DeviceStateOk, WaitTime: waitTime})
+	if err != nil {
+		util.Log(router, "Failed to send routing busy indication: %v", err)
+	}
+}
 
 // serve listens for incoming routing-related packets.
 func (router *Router) serve() {
@@ -113,29 +273,21 @@ func (router *Router) serve() {
 
 	defer close(router.inbound)
 
+	if router.frames != nil {
+		defer close(router.frames)
+	}
+
 	for msg := range router.sock.Inbound() {
 		switch msg := msg.(type) {
 		case *knxnet.RoutingInd:
 			// Try to push it to the client without blocking this goroutine too long.
-			router.pushInbound(msg.Payload)
-
-		case *knxnet.RoutingBusy:
-			var trandom time.Duration
-			// If Control is 0, we should add a specified random amount of time
-			// to the WaitTime. Otherwise, it is not specified, we just wait WaitTime.
-			if msg.Control == 0 {
-				trandom = time.Duration(rand.Float64()*50) * time.Millisecond
-			}
+			router.pushInbound(RouterFrame{msg.Payload, msg.Interface})
 
-			// Inhibit sending for the given time.
-			router.sendMu.Lock()
-
-			waitTime := msg.WaitTime + trandom
-			if waitTime > maxWaitTime {
-				waitTime = maxWaitTime
-			}
+		case *knxnet.RoutingSysBc:
+			router.pushInbound(RouterFrame{msg.Payload, msg.Interface})
 
-			time.AfterFunc(waitTime, router.sendMu.Unlock)
+		case *knxnet.RoutingBusy:
+			router.backoff.busy(msg)
 
 		case *knxnet.RoutingLost:
 			// Resend the last msg.Count messages.
@@ -149,32 +301,105 @@ func (router *Router) serve() {
 func NewRouter(multicastAddress string, config RouterConfig) (*Router, error) {
 	config = checkRouterConfig(config)
 
-	sock, err := knxnet.ListenRouterOnInterface(config.Interface, multicastAddress, config.MulticastLoopbackEnabled)
+	sockConfig := knxnet.RouterSocketConfig{
+		MulticastLoopback: config.MulticastLoopbackEnabled,
+		MulticastTTL:      config.MulticastTTL,
+	}
+
+	if config.Interface != nil {
+		sockConfig.Interfaces = append(sockConfig.Interfaces, config.Interface)
+	}
+
+	sockConfig.Interfaces = append(sockConfig.Interfaces, config.Interfaces...)
+
+	sock, err := knxnet.ListenRouterWithConfig(multicastAddress, sockConfig)
 	if err != nil {
 		return nil, err
 	}
 
+	return newRouter(sock, config), nil
+}
+
+// newRouter creates a Router which uses the given socket.
+func newRouter(sock knxnet.Socket, config RouterConfig) *Router {
 	r := &Router{
 		sock:          sock,
 		config:        config,
-		inbound:       make(chan cemi.Message),
 		retainer:      list.New(),
 		postSendPause: config.PostSendPauseDuration,
+		now:           time.Now,
+		backoff:       newBusyBackoff(),
+	}
+
+	var queueSize uint
+	if config.FlowControl != nil {
+		flowControl := checkFlowControlConfig(*config.FlowControl)
+		r.flowControl = &flowControl
+		queueSize = flowControl.QueueSize
+	}
+
+	r.inbound = make(chan cemi.Message, queueSize)
+	if config.ReportInterface {
+		r.frames = make(chan RouterFrame, queueSize)
+	}
+
+	if config.InboundFilter != nil {
+		r.filter = newInboundFilter(*config.InboundFilter)
 	}
 
 	go r.serve()
 
-	return r, nil
+	if config.Scheduler != nil {
+		r.scheduler = NewScheduler(r.send, *config.Scheduler)
+	}
+
+	return r
 }
 
-// Send transmits a packet.
+// Send transmits a packet. If the scheduler is enabled, Send only queues the frame. It then
+// returns nil unless the frame cannot be queued; transmission errors are only reflected in
+// SchedulerStats.
 func (router *Router) Send(data cemi.Message) (err error) {
 	if data == nil {
 		return errors.New("nil-pointers are not sendable")
 	}
 
-	// We lock this before doing any sending so the server goroutine can adjust the flow control.
-	router.sendMu.Lock()
+	if err := validateOutbound(data, router.config.MaxAPDULength); err != nil {
+		return err
+	}
+
+	if router.scheduler != nil {
+		return router.scheduler.Send(data)
+	}
+
+	return router.send(data)
+}
+
+// SchedulerStats returns the metrics of the send scheduler. ok is false if the scheduler is not
+// enabled.
+func (router *Router) SchedulerStats() (stats SchedulerStats, ok bool) {
+	if router.scheduler == nil {
+		return stats, false
+	}
+
+	return router.scheduler.Stats(), true
+}
+
+// send transmits a packet immediately.
+func (router *Router) send(data cemi.Message) (err error) {
+	// Only one frame is sent at a time, so that the pause after sending applies to all of them.
+	// Other routers may have asked us to pause. The pause is awaited without holding the lock,
+	// because resending lost frames must not stall the inbound processing.
+	for {
+		router.backoff.wait()
+		router.sendMu.Lock()
+
+		if router.backoff.delay() == 0 {
+			break
+		}
+
+		router.sendMu.Unlock()
+	}
 
 	defer func() {
 		// This is called as a goroutine in order to not block the return of Send.
@@ -187,7 +412,11 @@ func (router *Router) Send(data cemi.Message) (err error) {
 		}()
 	}()
 
-	err = router.sock.Send(&knxnet.RoutingInd{Payload: data})
+	if router.filter != nil {
+		router.filter.recordSent(data)
+	}
+
+	err = router.sock.Send(routingService(data))
 
 	if err == nil {
 		// Store this for potential resending.
@@ -204,14 +433,53 @@ func (router *Router) Send(data cemi.Message) (err error) {
 	return err
 }
 
+// routingService wraps the message in the appropriate routing service. System broadcast frames
+// have their own service.
+func routingService(msg cemi.Message) knxnet.ServicePackable {
+	var ldata *cemi.LData
+
+	switch msg := msg.(type) {
+	case *cemi.LDataReq:
+		ldata = &msg.LData
+
+	case *cemi.LDataInd:
+		ldata = &msg.LData
+	}
+
+	if ldata != nil && ldata.Control2.IsGroupAddr() && ldata.Destination == 0 &&
+		ldata.Control1.SysBroadcast() {
+		return &knxnet.RoutingSysBc{Payload: msg}
+	}
+
+	return &knxnet.RoutingInd{Payload: msg}
+}
+
 // Inbound returns the channel which transmits incoming data. The channel will be closed when the
 // underlying Socket closes its inbound channel (which happens on read errors or upon closing it).
 func (router *Router) Inbound() <-chan cemi.Message {
 	return router.inbound
 }
 
+// Frames returns the channel which transmits incoming data together with the interface on which
+// it has arrived. It is nil unless ReportInterface is enabled in the configuration. The channel
+// will be closed like the Inbound channel.
+func (router *Router) Frames() <-chan RouterFrame {
+	return router.frames
+}
+
 // Close closes the underlying socket and terminates the Router thereby.
 func (router *Router) Close() {
+	if router.scheduler != nil {
+		router.scheduler.Close()
+	}
+
+	router.lostMu.Lock()
+	if router.lostTimer != nil {
+		router.lostTimer.Stop()
+		router.lostTimer = nil
+	}
+	router.lostMu.Unlock()
+
 	router.sock.Close()
 }
 
@@ -219,15 +487,22 @@ func (router *Router) Close() {
 type GroupRouter struct {
 	*Router
 	inbound chan GroupEvent
+	readers *groupReaders
 }
 
-// NewGroupRouter creates a new Router for group communication.
+// NewGroupRouter creates a new Router for group communication. ReportInterface must not be
+// enabled, because it diverts the inbound messages from the group communication.
 func NewGroupRouter(multicastAddress string, config RouterConfig) (gr GroupRouter, err error) {
+	if config.ReportInterface {
+		return gr, errors.New("group routers do not support ReportInterface")
+	}
+
 	gr.Router, err = NewRouter(multicastAddress, config)
 
 	if err == nil {
 		gr.inbound = make(chan GroupEvent)
-		go serveGroupInbound(gr.Router.Inbound(), gr.inbound)
+		gr.readers = newGroupReaders()
+		go serveGroupInbound(gr.Router.Inbound(), gr.inbound, gr.readers)
 	}
 
 	return
@@ -238,7 +513,26 @@ func (gr *GroupRouter) Send(event GroupEvent) error {
 	return gr.Router.Send(&cemi.LDataInd{LData: buildGroupOutbound(event)})
 }
 
-// Inbound returns the channel on which group communication can be received.
+// Inbound returns the channel on which group communication can be received. If it is not consumed,
+// the latest events are kept and older ones are dropped.
 func (gr *GroupRouter) Inbound() <-chan GroupEvent {
 	return gr.inbound
 }
+
+// ReadGroup sends a GroupValueRead to the given address and returns the first response. The
+// request is repeated according to GroupReadTimeout and GroupReadAttempts of the configuration.
+// Responses are delivered on the inbound channel as well.
+func (gr *GroupRouter) ReadGroup(ctx context.Context, addr cemi.GroupAddr) (GroupEvent, error) {
+	config := gr.Router.config
+	return readGroup(ctx, gr, gr.readers, addr, config.GroupReadTimeout, config.GroupReadAttempts)
+}
+
+// WriteValue sends a GroupValueWrite with the given value.
+func (gr *GroupRouter) WriteValue(addr cemi.GroupAddr, value dpt.DatapointValue) error {
+	return writeValue(gr, GroupWrite, addr, value)
+}
+
+// Respond sends a GroupValueResponse with the given value.
+func (gr *GroupRouter) Respond(addr cemi.GroupAddr, value dpt.DatapointValue) error {
+	return writeValue(gr, GroupResponse, addr, value)
+}
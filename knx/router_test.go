@@ -0,0 +1,236 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/knxnet"
+)
+
+func makeRoutingInd(dest uint16) *knxnet.RoutingInd {
+	return &knxnet.RoutingInd{
+		Payload: &cemi.LDataInd{LData: buildGroupOutbound(GroupEvent{
+			Command:     GroupWrite,
+			Destination: cemi.GroupAddr(dest),
+			Data:        []byte{1},
+		})},
+	}
+}
+
+func TestRouter_FlowControl(t *testing.T) {
+	client, gateway := newDummySockets()
+	defer gateway.Close()
+
+	router := newRouter(client, checkRouterConfig(RouterConfig{
+		FlowControl: &FlowControlConfig{QueueSize: 4, BusyThreshold: 2},
+	}))
+	defer router.Close()
+
+	for i := 0; i < 6; i++ {
+		if err := gateway.Send(makeRoutingInd(uint16(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	busy, lost := 0, 0
+
+	for busy+lost < 2 {
+		select {
+		case srv := <-gateway.Inbound():
+			switch srv := srv.(type) {
+			case *knxnet.RoutingBusy:
+				if lost > 0 {
+					t.Error("Busy indication should precede the lost messages")
+				}
+
+				if srv.WaitTime != DefaultFlowControlConfig.BusyWaitTime {
+					t.Errorf("Unexpected wait time %v", srv.WaitTime)
+				}
+
+				busy++
+
+			case *knxnet.RoutingLost:
+				if srv.Count != 2 {
+					t.Errorf("Unexpected lost count %d", srv.Count)
+				}
+
+				lost++
+
+			default:
+				t.Fatalf("Unexpected service %v", srv)
+			}
+
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out with %d busy indications and %d lost messages", busy, lost)
+		}
+	}
+
+	if busy != 1 || lost != 1 {
+		t.Errorf("Expected 1 busy indication and 1 lost message, got %d and %d", busy, lost)
+	}
+
+	for i := 0; i < 4; i++ {
+		msg := <-router.Inbound()
+		if dest := msg.(*cemi.LDataInd).Destination; dest != uint16(i) {
+			t.Errorf("Unexpected frame for %v", dest)
+		}
+	}
+}
+
+func TestRouter_SendBackoff(t *testing.T) {
+	client, gateway := newDummySockets()
+	defer gateway.Close()
+
+	router := newRouter(client, checkRouterConfig(RouterConfig{}))
+	defer router.Close()
+
+	router.backoff.busy(&knxnet.RoutingBusy{WaitTime: 100 * time.Millisecond, Control: 1})
+
+	sent := make(chan error, 1)
+	go func() {
+		sent <- router.send(makeRoutingInd(1).Payload)
+	}()
+
+	// Resending lost frames happens on the inbound path and must not wait for the pause.
+	resent := make(chan struct{})
+	go func() {
+		router.resendLost(1)
+		close(resent)
+	}()
+
+	select {
+	case <-resent:
+	case <-sent:
+		t.Error("Frame should not have been sent during the pause")
+	case <-time.After(50 * time.Millisecond):
+		t.Error("Resending lost frames is blocked by the pause")
+	}
+
+	if err := <-sent; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := (<-gateway.Inbound()).(*knxnet.RoutingInd); !ok {
+		t.Error("Frame should have been sent after the pause")
+	}
+}
+
+func TestRouter_SysBroadcast(t *testing.T) {
+	client, gateway := newDummySockets()
+	defer gateway.Close()
+
+	router := newRouter(client, checkRouterConfig(RouterConfig{}))
+	defer router.Close()
+
+	sysBc := makeRoutingInd(0).Payload.(*cemi.LDataInd)
+	sysBc.Control1.SetSysBroadcast(true)
+
+	group := makeRoutingInd(1).Payload.(*cemi.LDataInd)
+	group.Control1.SetSysBroadcast(true)
+
+	for _, msg := range []cemi.Message{sysBc, group} {
+		if err := router.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if srv, ok := (<-gateway.Inbound()).(*knxnet.RoutingSysBc); !ok || srv.Payload != sysBc {
+		t.Errorf("System broadcast should have been sent as such: %v", srv)
+	}
+
+	if srv, ok := (<-gateway.Inbound()).(*knxnet.RoutingInd); !ok || srv.Payload != group {
+		t.Errorf("Group communication should have been sent as routing indication: %v", srv)
+	}
+
+	if err := gateway.Send(&knxnet.RoutingSysBc{Payload: sysBc}); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg := <-router.Inbound(); msg != sysBc {
+		t.Errorf("Unexpected inbound message %v", msg)
+	}
+}
+
+func TestRouter_ReportInterface(t *testing.T) {
+	client, gateway := newDummySockets()
+	defer gateway.Close()
+
+	router := newRouter(client, checkRouterConfig(RouterConfig{ReportInterface: true}))
+	defer router.Close()
+
+	ifi := &net.Interface{Index: 2, Name: "eth1"}
+
+	ind := makeRoutingInd(1)
+	ind.Interface = ifi
+
+	if err := gateway.Send(ind); err != nil {
+		t.Fatal(err)
+	}
+
+	if frame := <-router.Frames(); frame.Message != ind.Payload || frame.Interface != ifi {
+		t.Errorf("Unexpected frame %v", frame)
+	}
+}
+
+func TestGroupRouter_ReadGroup(t *testing.T) {
+	client, gateway := newDummySockets()
+	defer gateway.Close()
+
+	gr := GroupRouter{
+		Router:  newRouter(client, checkRouterConfig(RouterConfig{})),
+		inbound: make(chan GroupEvent),
+		readers: newGroupReaders(),
+	}
+	defer gr.Close()
+
+	go serveGroupInbound(gr.Router.Inbound(), gr.inbound, gr.readers)
+
+	// Nobody consumes the inbound channel of the group router.
+	for i := 0; i < 3; i++ {
+		if err := gateway.Send(makeRoutingInd(2)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	go func() {
+		for srv := range gateway.Inbound() {
+			ind, ok := srv.(*knxnet.RoutingInd)
+			if !ok {
+				continue
+			}
+
+			ldata := ind.Payload.(*cemi.LDataInd).LData
+			ldata.Data = &cemi.AppData{Command: cemi.GroupValueResponse, Data: []byte{7}}
+
+			if err := gateway.Send(&knxnet.RoutingInd{Payload: &cemi.LDataInd{LData: ldata}}); err != nil {
+				t.Error(err)
+			}
+
+			return
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	event, err := gr.ReadGroup(ctx, cemi.GroupAddr(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if event.Command != GroupResponse || event.Data[0] != 7 {
+		t.Errorf("Unexpected response %+v", event)
+	}
+}
+
+func TestNewGroupRouter_ReportInterface(t *testing.T) {
+	if _, err := NewGroupRouter("224.0.23.12:3671", RouterConfig{ReportInterface: true}); err == nil {
+		t.Error("Should not succeed with ReportInterface")
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/util"
+)
+
+// SchedulerConfig allows you to configure the outbound send scheduler.
+type SchedulerConfig struct {
+	// Rate is the number of telegrams per second that may be sent on average. A TP1 line carries
+	// about 50 telegrams per second.
+	Rate float64
+
+	// Burst is the number of telegrams that may be sent back-to-back after an idle period.
+	Burst uint
+
+	// Coalesce replaces pending GroupValueWrites to the same group address with the latest one.
+	Coalesce bool
+
+	// QueueLimit is the maximum number of pending frames per priority.
+	QueueLimit uint
+}
+
+// DefaultSchedulerConfig is a good default configuration for a send scheduler.
+var DefaultSchedulerConfig = SchedulerConfig{
+	Rate:       20,
+	Burst:      5,
+	QueueLimit: 1024,
+}
+
+// checkSchedulerConfig makes sure that the configuration is actually usable.
+func checkSchedulerConfig(config SchedulerConfig) SchedulerConfig {
+	if config.Rate <= 0 {
+		config.Rate = DefaultSchedulerConfig.Rate
+	}
+
+	if config.Burst == 0 {
+		config.Burst = DefaultSchedulerConfig.Burst
+	}
+
+	if config.QueueLimit == 0 {
+		config.QueueLimit = DefaultSchedulerConfig.QueueLimit
+	}
+
+	return config
+}
+
+// SchedulerStats contains the metrics of a send scheduler.
+type SchedulerStats struct {
+	// Number of pending frames, indexed by cemi.Priority
+	Queued [4]uint
+
+	Sent      uint64
+	Failed    uint64
+	Coalesced uint64
+	Rejected  uint64
+}
+
+// schedulerOrder is the order in which the priority queues are served.
+var schedulerOrder = [4]cemi.Priority{cemi.PrioSystem, cemi.PrioUrgent, cemi.PrioNormal, cemi.PrioLow}
+
+var (
+	errSchedulerClosed = errors.New("scheduler has been closed")
+	errQueueFull       = errors.New("send queue is full")
+)
+
+// A Scheduler queues outbound frames by priority and sends them at a limited rate.
+type Scheduler struct {
+	send   func(cemi.Message) error
+	config SchedulerConfig
+
+	mu      sync.Mutex
+	queues  [4]*list.List
+	pending map[cemi.GroupAddr]*list.Element
+	stats   SchedulerStats
+	closed  bool
+
+	wake chan struct{}
+	done chan struct{}
+	wait sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler which transmits the frames using the given function.
+func NewScheduler(send func(cemi.Message) error, config SchedulerConfig) *Scheduler {
+	sched := &Scheduler{
+		send:    send,
+		config:  checkSchedulerConfig(config),
+		pending: make(map[cemi.GroupAddr]*list.Element),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	for i := range sched.queues {
+		sched.queues[i] = list.New()
+	}
+
+	sched.wait.Add(1)
+	go sched.serve()
+
+	return sched
+}
+
+// classify determines the priority of the frame and the group address of a GroupValueWrite.
+func classify(msg cemi.Message) (cemi.Priority, cemi.GroupAddr, bool) {
+	var ldata *cemi.LData
+
+	switch msg := msg.(type) {
+	case *cemi.LDataReq:
+		ldata = &msg.LData
+
+	case *cemi.LDataInd:
+		ldata = &msg.LData
+
+	default:
+		return cemi.PrioNormal, 0, false
+	}
+
+	app, ok := ldata.Data.(*cemi.AppData)
+	isWrite := ok && app.Command == cemi.GroupValueWrite && ldata.Control2.IsGroupAddr()
+
+	return ldata.Control1.Priority(), cemi.GroupAddr(ldata.Destination), isWrite
+}
+
+// Send queues the frame. It returns an error if the queue for its priority is full. Whether the
+// frame has been transmitted successfully is only reflected in the statistics.
+func (sched *Scheduler) Send(msg cemi.Message) error {
+	prio, addr, isWrite := classify(msg)
+	coalesce := sched.config.Coalesce && isWrite
+
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+
+	if sched.closed {
+		return errSchedulerClosed
+	}
+
+	var replaced *list.Element
+	var replacedPrio cemi.Priority
+
+	if coalesce {
+		if elem, ok := sched.pending[addr]; ok {
+			replacedPrio, _, _ = classify(elem.Value.(cemi.Message))
+			if replacedPrio == prio {
+				elem.Value = msg
+				sched.stats.Coalesced++
+				return nil
+			}
+
+			// The pending frame sits in the queue of another priority. It is replaced by queueing
+			// the new one in the right queue.
+			replaced = elem
+		}
+	}
+
+	queue := sched.queues[prio]
+	if uint(queue.Len()) >= sched.config.QueueLimit {
+		sched.stats.Rejected++
+		return errQueueFull
+	}
+
+	if replaced != nil {
+		sched.queues[replacedPrio].Remove(replaced)
+		sched.stats.Coalesced++
+	}
+
+	elem := queue.PushBack(msg)
+	if coalesce {
+		sched.pending[addr] = elem
+	}
+
+	select {
+	case sched.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// next removes the frame with the highest priority from the queues.
+func (sched *Scheduler) next() (cemi.Message, bool) {
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+
+	for _, prio := range schedulerOrder {
+		queue := sched.queues[prio]
+		if queue.Len() == 0 {
+			continue
+		}
+
+		elem := queue.Front()
+		msg := queue.Remove(elem).(cemi.Message)
+
+		if _, addr, isWrite := classify(msg); isWrite && sched.pending[addr] == elem {
+			delete(sched.pending, addr)
+		}
+
+		return msg, true
+	}
+
+	return nil, false
+}
+
+// empty determines whether no frames are pending.
+func (sched *Scheduler) empty() bool {
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+
+	for _, queue := range sched.queues {
+		if queue.Len() > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// serve transmits the queued frames.
+func (sched *Scheduler) serve() {
+	defer sched.wait.Done()
+
+	tokens := float64(sched.config.Burst)
+	last := time.Now()
+
+	for {
+		if sched.empty() {
+			select {
+			case <-sched.wake:
+				continue
+			case <-sched.done:
+				return
+			}
+		}
+
+		// Refill the token bucket.
+		now := time.Now()
+		tokens += now.Sub(last).Seconds() * sched.config.Rate
+		if burst := float64(sched.config.Burst); tokens > burst {
+			tokens = burst
+		}
+		last = now
+
+		if tokens < 1 {
+			delay := time.Duration((1 - tokens) / sched.config.Rate * float64(time.Second))
+
+			select {
+			case <-time.After(delay):
+				continue
+			case <-sched.done:
+				return
+			}
+		}
+
+		// Frames are picked only after a token is available, so that frames with a higher
+		// priority can overtake while waiting.
+		msg, ok := sched.next()
+		if !ok {
+			continue
+		}
+
+		tokens--
+
+		err := sched.send(msg)
+
+		sched.mu.Lock()
+		if err == nil {
+			sched.stats.Sent++
+		} else {
+			sched.stats.Failed++
+		}
+		sched.mu.Unlock()
+
+		if err != nil {
+			util.Log(sched, "Failed to send frame: %v", err)
+		}
+	}
+}
+
+// Stats returns the current metrics.
+func (sched *Scheduler) Stats() SchedulerStats {
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+
+	stats := sched.stats
+	for prio, queue := range sched.queues {
+		stats.Queued[prio] = uint(queue.Len())
+	}
+
+	return stats
+}
+
+// Close stops the scheduler. Pending frames are discarded.
+func (sched *Scheduler) Close() {
+	sched.mu.Lock()
+	if sched.closed {
+		sched.mu.Unlock()
+		return
+	}
+	sched.closed = true
+	sched.mu.Unlock()
+
+	close(sched.done)
+	sched.wait.Wait()
+}
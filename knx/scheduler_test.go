@@ -0,0 +1,151 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package knx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+func makeScheduledWrite(prio cemi.Priority, dest cemi.GroupAddr, value byte) cemi.Message {
+	ldata := buildGroupOutbound(GroupEvent{Command: GroupWrite, Destination: dest, Data: []byte{value}})
+	ldata.Control1.SetPriority(prio)
+
+	return &cemi.LDataReq{LData: ldata}
+}
+
+func TestScheduler_Order(t *testing.T) {
+	gate := make(chan struct{})
+	sent := make(chan cemi.Message, 10)
+
+	sched := NewScheduler(func(msg cemi.Message) error {
+		<-gate
+		sent <- msg
+		return nil
+	}, SchedulerConfig{Rate: 1000, Burst: 10, Coalesce: true, QueueLimit: 2})
+	defer sched.Close()
+
+	addr := cemi.NewGroupAddr3(1, 0, 1)
+
+	// The first frame blocks the sender, so that the others queue up.
+	if err := sched.Send(makeScheduledWrite(cemi.PrioLow, cemi.NewGroupAddr3(1, 0, 9), 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	for sched.Stats().Queued[cemi.PrioLow] != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := byte(1); i <= 3; i++ {
+		if err := sched.Send(makeScheduledWrite(cemi.PrioLow, addr, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sched.Send(makeScheduledWrite(cemi.PrioLow, cemi.NewGroupAddr3(1, 0, 2), 4))
+	if err := sched.Send(makeScheduledWrite(cemi.PrioLow, cemi.NewGroupAddr3(1, 0, 3), 5)); err == nil {
+		t.Error("Queue limit should have been reached")
+	}
+
+	sched.Send(makeScheduledWrite(cemi.PrioUrgent, cemi.NewGroupAddr3(2, 0, 1), 6))
+	sched.Send(makeScheduledWrite(cemi.PrioSystem, cemi.NewGroupAddr3(3, 0, 1), 7))
+
+	stats := sched.Stats()
+	if stats.Queued != [4]uint{1, 0, 1, 2} || stats.Coalesced != 2 || stats.Rejected != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	close(gate)
+
+	var values []byte
+	for i := 0; i < 5; i++ {
+		msg := <-sent
+		values = append(values, msg.(*cemi.LDataReq).Data.(*cemi.AppData).Data[0])
+	}
+
+	expected := []byte{0, 7, 6, 3, 4}
+	if string(values) != string(expected) {
+		t.Errorf("Unexpected order %v, expected %v", values, expected)
+	}
+}
+
+func TestScheduler_CoalescePriority(t *testing.T) {
+	gate := make(chan struct{})
+	sent := make(chan cemi.Message, 10)
+
+	sched := NewScheduler(func(msg cemi.Message) error {
+		<-gate
+		sent <- msg
+		return nil
+	}, SchedulerConfig{Rate: 1000, Burst: 10, Coalesce: true})
+	defer sched.Close()
+
+	// The first frame blocks the sender, so that the others queue up.
+	sched.Send(makeScheduledWrite(cemi.PrioLow, cemi.NewGroupAddr3(1, 0, 9), 0))
+
+	for sched.Stats().Queued[cemi.PrioLow] != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	addr := cemi.NewGroupAddr3(1, 0, 1)
+	sched.Send(makeScheduledWrite(cemi.PrioLow, addr, 1))
+	sched.Send(makeScheduledWrite(cemi.PrioLow, cemi.NewGroupAddr3(1, 0, 2), 2))
+	sched.Send(makeScheduledWrite(cemi.PrioUrgent, addr, 3))
+
+	stats := sched.Stats()
+	if stats.Queued != [4]uint{0, 0, 1, 1} || stats.Coalesced != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	close(gate)
+
+	var values []byte
+	for i := 0; i < 3; i++ {
+		msg := <-sent
+		values = append(values, msg.(*cemi.LDataReq).Data.(*cemi.AppData).Data[0])
+	}
+
+	expected := []byte{0, 3, 2}
+	if string(values) != string(expected) {
+		t.Errorf("Unexpected order %v, expected %v", values, expected)
+	}
+}
+
+func TestScheduler_Rate(t *testing.T) {
+	sent := make(chan time.Time, 10)
+
+	sched := NewScheduler(func(msg cemi.Message) error {
+		sent <- time.Now()
+		return nil
+	}, SchedulerConfig{Rate: 50, Burst: 1})
+	defer sched.Close()
+
+	start := time.Now()
+
+	for i := byte(0); i < 4; i++ {
+		sched.Send(makeScheduledWrite(cemi.PrioLow, cemi.NewGroupAddr3(1, 0, 1), i))
+	}
+
+	var last time.Time
+	for i := 0; i < 4; i++ {
+		last = <-sent
+	}
+
+	// The first frame uses the burst token, the others have to wait 20ms each.
+	if elapsed := last.Sub(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Frames have been sent too fast: %v", elapsed)
+	}
+
+	sched.Close()
+
+	if stats := sched.Stats(); stats.Sent != 4 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if err := sched.Send(makeScheduledWrite(cemi.PrioLow, cemi.NewGroupAddr3(1, 0, 1), 0)); err == nil {
+		t.Error("Closed scheduler should not accept frames")
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+// Package tp1 provides the means to parse and generate KNX TP1 telegrams, as they are found in
+// L_Raw and L_Busmon.ind messages.
+package tp1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/util"
+)
+
+// Checksum computes the check octet for the given telegram contents.
+func Checksum(data []byte) byte {
+	var check byte
+
+	for _, b := range data {
+		check ^= b
+	}
+
+	return ^check
+}
+
+// A Frame is a TP1 telegram.
+type Frame interface {
+	util.Packable
+}
+
+// An Acknowledgement is a single-octet frame which acknowledges a data frame.
+type Acknowledgement uint8
+
+// These are the acknowledgement frames.
+const (
+	Ack     Acknowledgement = 0xCC
+	Nak     Acknowledgement = 0x0C
+	Busy    Acknowledgement = 0xC0
+	NakBusy Acknowledgement = 0x00
+)
+
+// String generates a string representation.
+func (ack Acknowledgement) String() string {
+	switch ack {
+	case Ack:
+		return "ACK"
+
+	case Nak:
+		return "NAK"
+
+	case Busy:
+		return "BUSY"
+
+	case NakBusy:
+		return "NAK+BUSY"
+
+	default:
+		return fmt.Sprintf("%#02x", uint8(ack))
+	}
+}
+
+// Size returns the packed size.
+func (Acknowledgement) Size() uint {
+	return 1
+}
+
+// Pack the frame into the buffer.
+func (ack Acknowledgement) Pack(buffer []byte) {
+	buffer[0] = byte(ack)
+}
+
+// Unpack initializes the frame by parsing the given data.
+func (ack *Acknowledgement) Unpack(data []byte) (uint, error) {
+	if len(data) < 1 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	switch Acknowledgement(data[0]) {
+	case Ack, Nak, Busy, NakBusy:
+		*ack = Acknowledgement(data[0])
+		return 1, nil
+
+	default:
+		return 0, fmt.Errorf("invalid acknowledgement frame %#02x", data[0])
+	}
+}
+
+const (
+	// controlMask selects the bits that identify a data frame in the control field.
+	controlMask = 0x53
+
+	// controlData is the value of the identifying bits for a data frame.
+	controlData = 0x10
+)
+
+// A DataFrame is a TP1 data telegram. It is transmitted as a standard frame if Control1StdFrame is
+// present and the frame does not need an extended frame. Additional info is not transmitted.
+type DataFrame struct {
+	cemi.LData
+}
+
+// extended determines whether the frame is transmitted as an extended frame.
+func (frame *DataFrame) extended() bool {
+	return frame.Control1&cemi.Control1StdFrame == 0 || frame.NeedsExtendedFrame()
+}
+
+// Size returns the packed size.
+func (frame *DataFrame) Size() uint {
+	// The transport unit includes the length field.
+	size := 6 + frame.Data.Size()
+
+	if frame.extended() {
+		size++
+	}
+
+	return size
+}
+
+// Pack the frame into the buffer.
+func (frame *DataFrame) Pack(buffer []byte) {
+	unit := make([]byte, frame.Data.Size())
+	frame.Data.Pack(unit)
+
+	control := byte(frame.Control1)&0xAC | controlData
+
+	if frame.extended() {
+		control &^= byte(cemi.Control1StdFrame)
+		util.PackSome(
+			buffer,
+			control,
+			uint8(frame.Control2),
+			uint16(frame.Source),
+			frame.Destination,
+			unit,
+		)
+	} else {
+		util.PackSome(
+			buffer,
+			control,
+			uint16(frame.Source),
+			frame.Destination,
+			byte(frame.Control2)&0xF0|unit[0]&15,
+			unit[1:],
+		)
+	}
+
+	n := frame.Size() - 1
+	buffer[n] = Checksum(buffer[:n])
+}
+
+// Unpack initializes the frame by parsing the given data. The data must contain exactly one
+// telegram.
+func (frame *DataFrame) Unpack(data []byte) (uint, error) {
+	if len(data) < 1 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	control := data[0]
+	if control&controlMask != controlData {
+		return 0, fmt.Errorf("invalid data frame control field %#02x", control)
+	}
+
+	// Reassemble the frame in the CEMI format, so that the transport unit parser can be reused.
+	var cemiData []byte
+
+	if control&byte(cemi.Control1StdFrame) != 0 {
+		if len(data) < 8 {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		length := int(data[5] & 15)
+		if len(data) != length+8 {
+			return 0, fmt.Errorf("standard frame length %d does not match length field %d", len(data), length)
+		}
+
+		cemiData = make([]byte, 0, len(data)+1)
+		cemiData = append(cemiData, 0, control&0xBC, data[5]&0xF0)
+		cemiData = append(cemiData, data[1:5]...)
+		cemiData = append(cemiData, byte(length))
+		cemiData = append(cemiData, data[6:len(data)-1]...)
+	} else {
+		if len(data) < 9 {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		length := int(data[6])
+		if len(data) != length+9 {
+			return 0, fmt.Errorf("extended frame length %d does not match length field %d", len(data), length)
+		}
+
+		cemiData = make([]byte, 0, len(data))
+		cemiData = append(cemiData, 0, control&0xBC)
+		cemiData = append(cemiData, data[1:len(data)-1]...)
+	}
+
+	if check := Checksum(data[:len(data)-1]); check != data[len(data)-1] {
+		return 0, fmt.Errorf("checksum mismatch: expected %#02x, got %#02x", check, data[len(data)-1])
+	}
+
+	if _, err := frame.LData.Unpack(cemiData); err != nil {
+		return 0, err
+	}
+
+	return uint(len(data)), nil
+}
+
+// Unpack parses a single TP1 telegram. Acknowledgements are returned as Acknowledgement values,
+// data frames as *DataFrame.
+func Unpack(data []byte, frame *Frame) (uint, error) {
+	if len(data) == 1 {
+		var ack Acknowledgement
+
+		n, err := ack.Unpack(data)
+		if err == nil {
+			*frame = ack
+		}
+
+		return n, err
+	}
+
+	dataFrame := &DataFrame{}
+
+	n, err := dataFrame.Unpack(data)
+	if err == nil {
+		*frame = dataFrame
+	}
+
+	return n, err
+}
+
+// Encode generates the telegram for the given L_Data frame. It fails if the frame is inconsistent.
+func Encode(ldata *cemi.LData) ([]byte, error) {
+	if ldata.Data == nil {
+		return nil, errors.New("frame does not contain a transport unit")
+	}
+
+	if err := ldata.Validate(0); err != nil {
+		return nil, err
+	}
+
+	frame := &DataFrame{LData: *ldata}
+	buffer := make([]byte, frame.Size())
+	frame.Pack(buffer)
+
+	return buffer, nil
+}
+
+// unpackWithInfo parses a message body that consists of additional info and a telegram.
+func unpackWithInfo(data []byte) (Frame, cemi.Info, error) {
+	var info cemi.Info
+
+	n, err := info.Unpack(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var frame Frame
+	if _, err := Unpack(data[n:], &frame); err != nil {
+		return nil, nil, err
+	}
+
+	return frame, info, nil
+}
+
+// UnpackBusmon parses the telegram inside a L_Busmon.ind message. The additional info, which
+// usually contains the busmonitor status and a timestamp, is returned as well.
+func UnpackBusmon(ind cemi.LBusmonInd) (Frame, cemi.Info, error) {
+	return unpackWithInfo(ind)
+}
+
+// UnpackRaw parses the telegram inside a L_Raw message body.
+func UnpackRaw(raw cemi.LRaw) (Frame, cemi.Info, error) {
+	return unpackWithInfo(raw)
+}
+
+// NewLRaw generates a L_Raw message body without additional info for the given telegram.
+func NewLRaw(frame Frame) cemi.LRaw {
+	buffer := make([]byte, 1+frame.Size())
+	frame.Pack(buffer[1:])
+
+	return cemi.LRaw(buffer)
+}
@@ -0,0 +1,120 @@
+// Copyright 2017 Ole Krüger.
+// Licensed under the MIT license which can be found in the LICENSE file.
+
+package tp1
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/vapourismo/knx-go/knx/cemi"
+)
+
+func TestChecksum(t *testing.T) {
+	// 1.1.1 -> 0/0/1 GroupValueWrite 1
+	data := []byte{0xBC, 0x11, 0x01, 0x00, 0x01, 0xE1, 0x00, 0x81}
+
+	if check := Checksum(data); check != 0x32 {
+		t.Errorf("Unexpected checksum %#02x", check)
+	}
+}
+
+func TestDataFrame(t *testing.T) {
+	standard := cemi.LData{
+		Control1:    cemi.Control1StdFrame | cemi.Control1NoRepeat | cemi.Control1NoSysBroadcast | cemi.Control1Prio(cemi.PrioLow),
+		Control2:    cemi.Control2GroupAddr | cemi.Control2Hops(6),
+		Source:      cemi.NewIndividualAddr3(1, 1, 1),
+		Destination: uint16(cemi.NewGroupAddr3(0, 0, 1)),
+		Data:        &cemi.AppData{Command: cemi.GroupValueWrite, Data: []byte{1}},
+	}
+
+	extended := standard
+	extended.Control1 &^= cemi.Control1StdFrame
+	extended.Data = &cemi.AppData{Command: cemi.GroupValueWrite, Data: bytes.Repeat([]byte{0x2A}, 20)}
+
+	lte := standard
+	lte.Control1 &^= cemi.Control1StdFrame
+	lte.Control2 = cemi.Control2LTE(cemi.LTEGeographical) | cemi.Control2Hops(6)
+
+	ack := standard
+	ack.Control2 = cemi.Control2Hops(6)
+	ack.Destination = uint16(cemi.NewIndividualAddr3(1, 1, 2))
+	ack.Data = &cemi.ControlData{Numbered: true, SeqNumber: 3, Command: 2}
+
+	cases := []struct {
+		name   string
+		ldata  cemi.LData
+		packed []byte
+	}{
+		{"Standard", standard, []byte{0xBC, 0x11, 0x01, 0x00, 0x01, 0xE1, 0x00, 0x81, 0x32}},
+		{"Extended", extended, nil},
+		{"LTE", lte, nil},
+		{"ControlData", ack, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := Encode(&c.ldata)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.packed != nil && !bytes.Equal(data, c.packed) {
+				t.Errorf("Unexpected telegram: %x", data)
+			}
+
+			var frame Frame
+			if _, err := Unpack(data, &frame); err != nil {
+				t.Fatal(err)
+			}
+
+			dataFrame, ok := frame.(*DataFrame)
+			if !ok {
+				t.Fatalf("Unexpected frame type %T", frame)
+			}
+
+			if !reflect.DeepEqual(dataFrame.LData, c.ldata) {
+				t.Errorf("Mismatch:\n%+v\n%+v", dataFrame.LData, c.ldata)
+			}
+		})
+	}
+}
+
+func TestUnpack_Invalid(t *testing.T) {
+	invalid := [][]byte{
+		{},
+		{0x42},
+		{0xBC, 0x11, 0x01, 0x00, 0x01, 0xE1, 0x00, 0x81, 0x34},
+		{0xBC, 0x11, 0x01, 0x00, 0x01, 0xE2, 0x00, 0x81, 0x30},
+		{0xFC, 0x11, 0x01, 0x00, 0x01, 0xE1, 0x00, 0x81, 0x73},
+		{0x3C, 0xE0, 0x11, 0x01, 0x00, 0x01, 0x05, 0x00, 0x81},
+	}
+
+	for _, data := range invalid {
+		var frame Frame
+		if _, err := Unpack(data, &frame); err == nil {
+			t.Errorf("Should not succeed: %x", data)
+		}
+	}
+}
+
+func TestUnpackBusmon(t *testing.T) {
+	frame, info, err := UnpackBusmon(cemi.LBusmonInd{0x03, 0x03, 0x01, 0x00, byte(Ack)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(info, []byte{0x03, 0x01, 0x00}) {
+		t.Errorf("Unexpected info: %v", info)
+	}
+
+	if frame != Ack {
+		t.Errorf("Unexpected frame: %v", frame)
+	}
+
+	raw := NewLRaw(Nak)
+	if frame, _, err := UnpackRaw(raw); err != nil || frame != Nak {
+		t.Errorf("Unexpected frame %v or error %v", frame, err)
+	}
+}
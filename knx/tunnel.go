@@ -4,12 +4,14 @@
 package knx
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
 	"github.com/vapourismo/knx-go/knx/knxnet"
 	"github.com/vapourismo/knx-go/knx/util"
 )
@@ -30,6 +32,25 @@ type TunnelConfig struct {
 
 	// UseTCP configures whether to connect to the gateway using TCP.
 	UseTCP bool
+
+	// MaxAPDULength limits the APDU length of outgoing L_Data frames. Use the value the gateway
+	// reports for its device object. 0 permits up to cemi.MaxExtendedAPDULength.
+	MaxAPDULength uint
+
+	// GroupReadTimeout specifies how long GroupTunnel.ReadGroup waits for a response before it
+	// repeats the request.
+	GroupReadTimeout time.Duration
+
+	// GroupReadAttempts is the number of requests GroupTunnel.ReadGroup sends at most.
+	GroupReadAttempts uint
+
+	// Scheduler enables the outbound send scheduler if it is not nil. Send then only queues the
+	// frame; transmission errors are reflected in the scheduler statistics.
+	Scheduler *SchedulerConfig
+
+	// InboundFilter enables the deduplication of inbound frames and the suppression of echoes of
+	// our own frames if it is not nil.
+	InboundFilter *InboundFilterConfig
 }
 
 // DefaultTunnelConfig is a good default configuration for a Tunnel client.
@@ -37,6 +58,8 @@ var DefaultTunnelConfig = TunnelConfig{
 	ResendInterval:    500 * time.Millisecond,
 	HeartbeatInterval: 10 * time.Second,
 	ResponseTimeout:   10 * time.Second,
+	GroupReadTimeout:  2 * time.Second,
+	GroupReadAttempts: 3,
 	SendLocalAddress:  false,
 	UseTCP:            false,
 }
@@ -55,11 +78,20 @@ func checkTunnelConfig(config TunnelConfig) TunnelConfig {
 		config.ResponseTimeout = DefaultTunnelConfig.ResponseTimeout
 	}
 
+	if config.GroupReadTimeout <= 0 {
+		config.GroupReadTimeout = DefaultTunnelConfig.GroupReadTimeout
+	}
+
+	if config.GroupReadAttempts == 0 {
+		config.GroupReadAttempts = DefaultTunnelConfig.GroupReadAttempts
+	}
+
 	return config
 }
 
 var (
 	errResponseTimeout = errors.New("response timeout reached")
+	errTunnelClosed    = errors.New("tunnel has been closed")
 )
 
 // A Tunnel provides methods to communicate with a KNXnet/IP gateway.
@@ -77,8 +109,10 @@ type Tunnel struct {
 	seqMu     sync.Mutex
 	seqNumber uint8
 	ack       chan *knxnet.TunnelRes
+	scheduler *Scheduler
 
 	// Incoming requests
+	filter  *inboundFilter
 	inbound chan cemi.Message
 
 	// Goroutine controller
@@ -161,6 +195,10 @@ func (conn *Tunnel) requestConn() (err error) {
 				case knxnet.NoError:
 					conn.channel = res.Channel
 
+					if conn.filter != nil {
+						conn.filter.setLocalAddr(res.Address)
+					}
+
 					conn.seqMu.Lock()
 					conn.seqNumber = 0
 					conn.seqMu.Unlock()
@@ -235,6 +273,11 @@ func (conn *Tunnel) requestDisc() error {
 
 // requestTunnel sends a tunnel request to the gateway and waits for an appropriate acknowledgement.
 func (conn *Tunnel) requestTunnel(data cemi.Message) error {
+	if conn.filter != nil {
+		// The echo may arrive before the request has been acknowledged.
+		conn.filter.recordSent(data)
+	}
+
 	// Sequence numbers cannot be reused, therefore we must protect against that.
 	conn.seqMu.Lock()
 	defer conn.seqMu.Unlock()
@@ -273,6 +316,10 @@ func (conn *Tunnel) requestTunnel(data cemi.Message) error {
 
 	for {
 		select {
+		// The connection is being closed.
+		case <-conn.done:
+			return errTunnelClosed
+
 		// Timeout reached.
 		case <-timeout:
 			return errResponseTimeout
@@ -356,6 +403,10 @@ func (conn *Tunnel) handleDiscRes(res *knxnet.DiscRes) error {
 // pushInbound sends the message through the inbound channel. If the sending blocks, it will launch
 // a goroutine which will do the sending.
 func (conn *Tunnel) pushInbound(msg cemi.Message) {
+	if conn.filter != nil && conn.filter.drop(msg) {
+		return
+	}
+
 	select {
 	case conn.inbound <- msg:
 
@@ -605,6 +656,10 @@ func NewTunnel(
 		done:    make(chan struct{}),
 	}
 
+	if config.InboundFilter != nil {
+		client.filter = newInboundFilter(*config.InboundFilter)
+	}
+
 	// Connect to the gateway.
 	err = client.requestConn()
 	if err != nil {
@@ -615,6 +670,10 @@ func NewTunnel(
 	client.wait.Add(1)
 	go client.serve()
 
+	if config.Scheduler != nil {
+		client.scheduler = NewScheduler(client.requestTunnel, *config.Scheduler)
+	}
+
 	return client, nil
 }
 
@@ -624,7 +683,13 @@ func (conn *Tunnel) Close() {
 	conn.once.Do(func() {
 		conn.requestDisc()
 
+		// Closing done first cancels a request which the scheduler might be waiting for.
 		close(conn.done)
+
+		if conn.scheduler != nil {
+			conn.scheduler.Close()
+		}
+
 		conn.wait.Wait()
 
 		conn.sock.Close()
@@ -637,15 +702,36 @@ func (conn *Tunnel) Inbound() <-chan cemi.Message {
 	return conn.inbound
 }
 
-// Send relays a tunnel request to the gateway with the given contents.
+// Send relays a tunnel request to the gateway with the given contents. If the scheduler is
+// enabled, Send only queues the frame. It then returns nil unless the frame cannot be queued;
+// transmission errors are only reflected in SchedulerStats.
 func (conn *Tunnel) Send(data cemi.Message) error {
+	if err := validateOutbound(data, conn.config.MaxAPDULength); err != nil {
+		return err
+	}
+
+	if conn.scheduler != nil {
+		return conn.scheduler.Send(data)
+	}
+
 	return conn.requestTunnel(data)
 }
 
+// SchedulerStats returns the metrics of the send scheduler. ok is false if the scheduler is not
+// enabled.
+func (conn *Tunnel) SchedulerStats() (stats SchedulerStats, ok bool) {
+	if conn.scheduler == nil {
+		return stats, false
+	}
+
+	return conn.scheduler.Stats(), true
+}
+
 // GroupTunnel is a Tunnel that provides only a group communication interface.
 type GroupTunnel struct {
 	*Tunnel
 	inbound chan GroupEvent
+	readers *groupReaders
 }
 
 // NewGroupTunnel creates a new Tunnel for group communication.
@@ -654,7 +740,8 @@ func NewGroupTunnel(gatewayAddr string, config TunnelConfig) (gt GroupTunnel, er
 
 	if err == nil {
 		gt.inbound = make(chan GroupEvent)
-		go serveGroupInbound(gt.Tunnel.Inbound(), gt.inbound)
+		gt.readers = newGroupReaders()
+		go serveGroupInbound(gt.Tunnel.Inbound(), gt.inbound, gt.readers)
 	}
 
 	return
@@ -665,7 +752,26 @@ func (gt *GroupTunnel) Send(event GroupEvent) error {
 	return gt.Tunnel.Send(&cemi.LDataReq{LData: buildGroupOutbound(event)})
 }
 
-// Inbound returns the channel on which group communication can be received.
+// Inbound returns the channel on which group communication can be received. If it is not consumed,
+// the latest events are kept and older ones are dropped.
 func (gt *GroupTunnel) Inbound() <-chan GroupEvent {
 	return gt.inbound
 }
+
+// ReadGroup sends a GroupValueRead to the given address and returns the first response. The
+// request is repeated according to GroupReadTimeout and GroupReadAttempts of the configuration.
+// Responses are delivered on the inbound channel as well.
+func (gt *GroupTunnel) ReadGroup(ctx context.Context, addr cemi.GroupAddr) (GroupEvent, error) {
+	config := gt.Tunnel.config
+	return readGroup(ctx, gt, gt.readers, addr, config.GroupReadTimeout, config.GroupReadAttempts)
+}
+
+// WriteValue sends a GroupValueWrite with the given value.
+func (gt *GroupTunnel) WriteValue(addr cemi.GroupAddr, value dpt.DatapointValue) error {
+	return writeValue(gt, GroupWrite, addr, value)
+}
+
+// Respond sends a GroupValueResponse with the given value.
+func (gt *GroupTunnel) Respond(addr cemi.GroupAddr, value dpt.DatapointValue) error {
+	return writeValue(gt, GroupResponse, addr, value)
+}
@@ -984,3 +984,48 @@ func TestTunnelConn_handleTunnelRes(t *testing.T) {
 		})
 	})
 }
+
+func TestTunnel_CloseScheduled(t *testing.T) {
+	client, gateway := newDummySockets()
+	defer gateway.Close()
+
+	config := DefaultTunnelConfig
+	config.ResponseTimeout = time.Minute
+
+	conn := makeTunnelConn(client, config, 1)
+	conn.done = make(chan struct{})
+	conn.scheduler = NewScheduler(conn.requestTunnel, DefaultSchedulerConfig)
+
+	ldata := buildGroupOutbound(GroupEvent{
+		Command:     GroupWrite,
+		Destination: cemi.NewGroupAddr3(1, 2, 3),
+		Data:        []byte{1},
+	})
+
+	if err := conn.Send(&cemi.LDataReq{LData: ldata}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The gateway never acknowledges the request.
+	for msg := range gateway.Inbound() {
+		if _, ok := msg.(*knxnet.TunnelReq); ok {
+			break
+		}
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		conn.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close is blocked by the pending request")
+	}
+
+	if stats, _ := conn.SchedulerStats(); stats.Failed != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
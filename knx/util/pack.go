@@ -5,6 +5,7 @@ package util
 
 import (
 	"fmt"
+	"sync"
 
 	"golang.org/x/text/encoding/charmap"
 )
@@ -20,6 +21,29 @@ type Packable interface {
 	Pack(buffer []byte)
 }
 
+// PackUInt16 packs a big-endian uint16. It avoids the type switch of Pack in hot paths.
+func PackUInt16(buffer []byte, input uint16) uint {
+	buffer[1] = uint8(input)
+	buffer[0] = uint8(input >> 8)
+	return 2
+}
+
+// PackUInt32 packs a big-endian uint32.
+func PackUInt32(buffer []byte, input uint32) uint {
+	buffer[3] = uint8(input)
+	buffer[2] = uint8(input >> 8)
+	buffer[1] = uint8(input >> 16)
+	buffer[0] = uint8(input >> 24)
+	return 4
+}
+
+// PackUInt64 packs a big-endian uint64.
+func PackUInt64(buffer []byte, input uint64) uint {
+	PackUInt32(buffer, uint32(input>>32))
+	PackUInt32(buffer[4:], uint32(input))
+	return 8
+}
+
 // Pack a value into the buffer.
 func Pack(buffer []byte, input interface{}) uint {
 	switch input := input.(type) {
@@ -32,53 +56,22 @@ func Pack(buffer []byte, input interface{}) uint {
 		return 1
 
 	case uint16:
-		buffer[1] = uint8(input)
-		buffer[0] = uint8(input >> 8)
-		return 2
+		return PackUInt16(buffer, input)
 
 	case int16:
-		uinput := uint16(input)
-		buffer[1] = uint8(uinput)
-		buffer[0] = uint8(uinput >> 8)
-		return 2
+		return PackUInt16(buffer, uint16(input))
 
 	case uint32:
-		buffer[3] = uint8(input)
-		buffer[2] = uint8(input >> 8)
-		buffer[1] = uint8(input >> 16)
-		buffer[0] = uint8(input >> 24)
-		return 4
+		return PackUInt32(buffer, input)
 
 	case int32:
-		uinput := uint32(input)
-		buffer[3] = uint8(uinput)
-		buffer[2] = uint8(uinput >> 8)
-		buffer[1] = uint8(uinput >> 16)
-		buffer[0] = uint8(uinput >> 24)
-		return 4
+		return PackUInt32(buffer, uint32(input))
 
 	case uint64:
-		buffer[7] = uint8(input)
-		buffer[6] = uint8(input >> 8)
-		buffer[5] = uint8(input >> 16)
-		buffer[4] = uint8(input >> 24)
-		buffer[3] = uint8(input >> 32)
-		buffer[2] = uint8(input >> 40)
-		buffer[1] = uint8(input >> 48)
-		buffer[0] = uint8(input >> 56)
-		return 8
+		return PackUInt64(buffer, input)
 
 	case int64:
-		uinput := uint64(input)
-		buffer[7] = uint8(uinput)
-		buffer[6] = uint8(uinput >> 8)
-		buffer[5] = uint8(uinput >> 16)
-		buffer[4] = uint8(uinput >> 24)
-		buffer[3] = uint8(uinput >> 32)
-		buffer[2] = uint8(uinput >> 40)
-		buffer[1] = uint8(uinput >> 48)
-		buffer[0] = uint8(uinput >> 56)
-		return 8
+		return PackUInt64(buffer, uint64(input))
 
 	case []byte:
 		return uint(copy(buffer, input))
@@ -117,6 +110,60 @@ func AllocAndPack(inputs ...Packable) []byte {
 	return buffer
 }
 
+// Extend grows the slice by n zeroed bytes. It only allocates if the capacity is insufficient.
+func Extend(dst []byte, n uint) []byte {
+	offset := len(dst)
+	end := offset + int(n)
+
+	if end > cap(dst) {
+		grown := make([]byte, end, 2*end)
+		copy(grown, dst)
+		return grown
+	}
+
+	dst = dst[:end]
+
+	tail := dst[offset:]
+	for i := range tail {
+		tail[i] = 0
+	}
+
+	return dst
+}
+
+// AppendPack appends the packed inputs to dst and returns the extended slice. Unlike AllocAndPack,
+// it only allocates if dst lacks the capacity.
+func AppendPack(dst []byte, inputs ...Packable) []byte {
+	for _, input := range inputs {
+		offset := len(dst)
+		dst = Extend(dst, input.Size())
+		input.Pack(dst[offset:])
+	}
+
+	return dst
+}
+
+// bufferPool contains buffers that are large enough for most KNXnet/IP packets.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		buffer := make([]byte, 0, 512)
+		return &buffer
+	},
+}
+
+// GetBuffer retrieves an empty buffer from a pool. Return it using PutBuffer when it is no longer
+// used.
+func GetBuffer() *[]byte {
+	buffer := bufferPool.Get().(*[]byte)
+	*buffer = (*buffer)[:0]
+	return buffer
+}
+
+// PutBuffer returns the buffer to the pool. The buffer must not be used afterwards.
+func PutBuffer(buffer *[]byte) {
+	bufferPool.Put(buffer)
+}
+
 // PackString packs a string into the buffer
 func PackString(buffer []byte, maxLen uint, input string) (uint, error) {
 	encoded, err := stringEncoder.Bytes([]byte(input))
@@ -4,6 +4,7 @@
 package util
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -36,3 +37,48 @@ func TestPackString(t *testing.T) {
 	}
 
 }
+
+type packableBytes []byte
+
+func (p packableBytes) Size() uint {
+	return uint(len(p))
+}
+
+func (p packableBytes) Pack(buffer []byte) {
+	for i, b := range p {
+		buffer[i] |= b
+	}
+}
+
+func TestAppendPack(t *testing.T) {
+	buffer := make([]byte, 0, 8)
+	buffer = AppendPack(buffer, packableBytes{1, 2}, packableBytes{3})
+
+	if !bytes.Equal(buffer, []byte{1, 2, 3}) {
+		t.Errorf("Unexpected buffer: %v", buffer)
+	}
+
+	// Reused memory must be zeroed before packing.
+	buffer = AppendPack(buffer[:1], packableBytes{4, 4})
+
+	if !bytes.Equal(buffer, []byte{1, 4, 4}) {
+		t.Errorf("Unexpected buffer: %v", buffer)
+	}
+
+	buffer = AppendPack(buffer, packableBytes(make([]byte, 10)))
+	if len(buffer) != 13 {
+		t.Errorf("Unexpected length: %d", len(buffer))
+	}
+}
+
+func BenchmarkAppendPack(b *testing.B) {
+	b.ReportAllocs()
+
+	var input Packable = packableBytes{1, 2, 3, 4}
+
+	for i := 0; i < b.N; i++ {
+		buffer := GetBuffer()
+		*buffer = AppendPack(*buffer, input)
+		PutBuffer(buffer)
+	}
+}
@@ -18,7 +18,8 @@ type Unpackable interface {
 	Unpack(data []byte) (uint, error)
 }
 
-func unpackUInt16(data []byte, output *uint16) (uint, error) {
+// UnpackUInt16 unpacks a big-endian uint16. It avoids the type switch of Unpack in hot paths.
+func UnpackUInt16(data []byte, output *uint16) (uint, error) {
 	if len(data) < 2 {
 		return 0, io.ErrUnexpectedEOF
 	}
@@ -28,7 +29,8 @@ func unpackUInt16(data []byte, output *uint16) (uint, error) {
 	return 2, nil
 }
 
-func unpackUInt32(data []byte, output *uint32) (uint, error) {
+// UnpackUInt32 unpacks a big-endian uint32.
+func UnpackUInt32(data []byte, output *uint32) (uint, error) {
 	if len(data) < 4 {
 		return 0, io.ErrUnexpectedEOF
 	}
@@ -38,7 +40,8 @@ func unpackUInt32(data []byte, output *uint32) (uint, error) {
 	return 4, nil
 }
 
-func unpackUInt64(data []byte, output *uint64) (uint, error) {
+// UnpackUInt64 unpacks a big-endian uint64.
+func UnpackUInt64(data []byte, output *uint64) (uint, error) {
 	if len(data) < 8 {
 		return 0, io.ErrUnexpectedEOF
 	}
@@ -69,29 +72,29 @@ func Unpack(data []byte, output interface{}) (uint, error) {
 		return 1, nil
 
 	case *uint16:
-		return unpackUInt16(data, output)
+		return UnpackUInt16(data, output)
 
 	case *int16:
 		var u uint16
-		n, err := unpackUInt16(data, &u)
+		n, err := UnpackUInt16(data, &u)
 		*output = int16(u)
 		return n, err
 
 	case *uint32:
-		return unpackUInt32(data, output)
+		return UnpackUInt32(data, output)
 
 	case *int32:
 		var u uint32
-		n, err := unpackUInt32(data, &u)
+		n, err := UnpackUInt32(data, &u)
 		*output = int32(u)
 		return n, err
 
 	case *uint64:
-		return unpackUInt64(data, output)
+		return UnpackUInt64(data, output)
 
 	case *int64:
 		var u uint64
-		n, err := unpackUInt64(data, &u)
+		n, err := UnpackUInt64(data, &u)
 		*output = int64(u)
 		return n, err
 